@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/harshk200/snippetbox/internal/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+func (app *application) apiSnippetList(w http.ResponseWriter, r *http.Request) {
+	snippets, err := app.snippetModel.Latest()
+	if err != nil {
+		app.apiServerError(w, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"snippets": app.redactContent(r, snippets)}, nil)
+	if err != nil {
+		app.apiServerError(w, err)
+	}
+}
+
+func (app *application) apiSnippetView(w http.ResponseWriter, r *http.Request) {
+	// NOTE: the :id is the numeric ID or the slug like on the html view, the unlisted snippets are only visible by
+	// their slug (unless it's the owner asking)
+	params := httprouter.ParamsFromContext(r.Context())
+
+	snippet, err := app.snippetByRef(r, params.ByName("id"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.apiNotFound(w)
+		} else {
+			app.apiServerError(w, err)
+		}
+
+		return
+	}
+
+	// NOTE: there is no unlocking through the api so only the owner gets to see protected snippets
+	if !app.isUnlocked(r, snippet) {
+		app.apiErrorResponse(w, http.StatusForbidden, "this snippet is password protected")
+		return
+	}
+
+	snippet, err = app.burnIfNeeded(r, snippet)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.apiNotFound(w)
+		} else {
+			app.apiServerError(w, err)
+		}
+
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"snippet": snippet}, nil)
+	if err != nil {
+		app.apiServerError(w, err)
+	}
+}
+
+// NOTE: the request-body is decoded into the same struct (and validated the same way) as the html create form
+func (app *application) apiSnippetCreate(w http.ResponseWriter, r *http.Request) {
+	// NOTE: everything but the title and content is optional for api clients (same defaults as the create form)
+	input := newSnippetCreateFormData()
+	err := app.readJSON(w, r, input)
+	if err != nil {
+		app.apiBadRequest(w, err)
+		return
+	}
+
+	input.validate()
+
+	if !input.Valid() {
+		app.apiFailedValidation(w, input.Validator)
+		return
+	}
+
+	id, err := app.snippetModel.Insert(input.Title, input.Content, input.Language, input.Visibility, input.Password, input.BurnAfterReading, input.expiresDuration(), app.authenticatedUserID(r), 0)
+	if err != nil {
+		app.apiServerError(w, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/api/v1/snippets/%d", id))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"id": id}, headers)
+	if err != nil {
+		app.apiServerError(w, err)
+	}
+}
+
+// returns the snippets with the content of the (still locked) password protected and the burn after reading ones
+// left out. listing a burn after reading snippet doesn't count as reading it
+func (app *application) redactContent(r *http.Request, snippets []*models.Snippet) []*models.Snippet {
+	redacted := make([]*models.Snippet, len(snippets))
+
+	for i, s := range snippets {
+		isOwner := app.isAuthenticated(r) && s.UserID == app.authenticatedUserID(r)
+
+		if app.isUnlocked(r, s) && (!s.BurnAfterReading || isOwner) {
+			redacted[i] = s
+			continue
+		}
+
+		locked := *s
+		locked.Content = ""
+		redacted[i] = &locked
+	}
+
+	return redacted
+}
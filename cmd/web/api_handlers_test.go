@@ -0,0 +1,221 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestAPISnippetList(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, header, body := ts.get(t, "/api/v1/snippets")
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, header.Get("Content-Type"), "application/json")
+	assert.StringContains(t, body, `"title":"test..."`)
+}
+
+func TestAPISnippetView(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid ID",
+			urlPath:  "/api/v1/snippets/69",
+			wantCode: http.StatusOK,
+			wantBody: `"snippet":{"id":69,"slug":"mockSlugPublic69","title":"test..."`,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/api/v1/snippets/123",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"Not Found"}`,
+		},
+		{
+			name:     "Unlisted by slug",
+			urlPath:  "/api/v1/snippets/mockSlugUnlisted",
+			wantCode: http.StatusOK,
+			wantBody: `"visibility":"unlisted"`,
+		},
+		{
+			name:     "Unlisted by ID",
+			urlPath:  "/api/v1/snippets/43",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"Not Found"}`,
+		},
+		{
+			name:     "Private by slug",
+			urlPath:  "/api/v1/snippets/mockSlugPrivate",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"Not Found"}`,
+		},
+		{
+			name:     "Private snippet",
+			urlPath:  "/api/v1/snippets/44",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"Not Found"}`,
+		},
+		{
+			name:     "String ID",
+			urlPath:  "/api/v1/snippets/foo",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"Not Found"}`,
+		},
+		{
+			name:     "Unknown api route",
+			urlPath:  "/api/v1/foo",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"Not Found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Content-Type"), "application/json")
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetCreate(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+	header := http.Header{}
+	header.Set("X-CSRF-Token", extractCSRFToken(t, body))
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, _, body := ts.postJSON(t, "/api/v1/snippets", header, `{"title":"a","content":"b","expires":"in","expires_in":7,"expires_unit":"days"}`)
+
+		assert.Equal(t, code, http.StatusUnauthorized)
+		assert.Equal(t, body, `{"error":"Unauthorized"}`)
+	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		payload  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid submission",
+			payload:  `{"title":"a","content":"b","expires":"in","expires_in":7,"expires_unit":"days"}`,
+			wantCode: http.StatusCreated,
+			wantBody: `{"id":420}`,
+		},
+		{
+			name:     "Failed validation",
+			payload:  `{"title":"","content":"b","expires":"later"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `{"error":"Unprocessable Entity","field_errors":{"expires":"This field can only be in, at or never","title":"This field cannot be blank"}}`,
+		},
+		{
+			name:     "Unknown field",
+			payload:  `{"title":"a","content":"b","expires":"in","expires_in":7,"expires_unit":"days","foo":1}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error":"body contains unknown field \"foo\""}`,
+		},
+		{
+			name:     "Badly-formed JSON",
+			payload:  `{"title":"a",`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error":"body contains badly-formed JSON"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.postJSON(t, "/api/v1/snippets", header, tt.payload)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, body, tt.wantBody)
+		})
+	}
+
+	t.Run("Unverified email", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/user/login")
+		header := http.Header{}
+		header.Set("X-CSRF-Token", extractCSRFToken(t, body))
+
+		ts.loginAs(t, "unverified@example.com", "password")
+
+		code, _, body := ts.postJSON(t, "/api/v1/snippets", header, `{"title":"a","content":"b","expires":"in","expires_in":7,"expires_unit":"days"}`)
+
+		assert.Equal(t, code, http.StatusForbidden)
+		assert.Equal(t, body, `{"error":"your email address must be verified before creating snippets"}`)
+	})
+
+	t.Run("Missing CSRF token", func(t *testing.T) {
+		code, _, body := ts.postJSON(t, "/api/v1/snippets", nil, `{"title":"a","content":"b","expires":"in","expires_in":7,"expires_unit":"days"}`)
+
+		assert.Equal(t, code, http.StatusBadRequest)
+		assert.Equal(t, body, `{"error":"Bad Request"}`)
+	})
+}
+
+func TestAPITokenAuthentication(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	const payload = `{"title":"a","content":"b","expires":"in","expires_in":7,"expires_unit":"days"}`
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      int
+		wantBody      string
+	}{
+		{
+			name:          "Valid token without CSRF token",
+			authorization: "Bearer valid-token",
+			wantCode:      http.StatusCreated,
+			wantBody:      `{"id":420}`,
+		},
+		{
+			name:          "Invalid token",
+			authorization: "Bearer invalid-token",
+			wantCode:      http.StatusUnauthorized,
+			wantBody:      `{"error":"invalid or missing api token"}`,
+		},
+		{
+			name:          "Wrong scheme",
+			authorization: "Basic valid-token",
+			wantCode:      http.StatusUnauthorized,
+			wantBody:      `{"error":"invalid or missing api token"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("Authorization", tt.authorization)
+
+			code, _, body := ts.postJSON(t, "/api/v1/snippets", header, payload)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, body, tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/harshk200/snippetbox/internal/validator"
+)
+
+// NOTE: every json response is wrapped in an envelope i.e. {"snippet": {...}} instead of just {...}
+type envelope map[string]any
+
+func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range headers {
+		w.Header()[key] = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+
+	return nil
+}
+
+// decodes the json request-body into dst (only a single json value of max 1MB is allowed)
+func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, 1_048_576)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var invalidUnmarshalError *json.InvalidUnmarshalError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return fmt.Errorf("body contains unknown field %s", fieldName)
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		case errors.As(err, &invalidUnmarshalError):
+			panic(err) // NOTE: same as decodePostForm, passing a non-pointer dst is a bug in our code
+		default:
+			return err
+		}
+	}
+
+	// NOTE: decoding again to make sure the body only had a single json value
+	err = dec.Decode(&struct{}{})
+	if !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+// NOTE: the api* error helpers mirror serverError, clientError and notFound but respond with json
+func (app *application) apiErrorResponse(w http.ResponseWriter, status int, message any) {
+	err := app.writeJSON(w, status, envelope{"error": message}, nil)
+	if err != nil {
+		app.errorLog.Output(2, err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (app *application) apiServerError(w http.ResponseWriter, err error) {
+	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
+	app.errorLog.Output(2, trace)
+
+	app.apiErrorResponse(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+}
+
+func (app *application) apiClientError(w http.ResponseWriter, status int) {
+	app.apiErrorResponse(w, status, http.StatusText(status))
+}
+
+func (app *application) apiNotFound(w http.ResponseWriter) {
+	app.apiClientError(w, http.StatusNotFound)
+}
+
+func (app *application) apiBadRequest(w http.ResponseWriter, err error) {
+	app.apiErrorResponse(w, http.StatusBadRequest, err.Error())
+}
+
+func (app *application) invalidAPIToken(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	app.apiErrorResponse(w, http.StatusUnauthorized, "invalid or missing api token")
+}
+
+// responds with the validator's field (and non-field) errors as json with a 422 status
+func (app *application) apiFailedValidation(w http.ResponseWriter, v validator.Validator) {
+	data := envelope{
+		"error":        http.StatusText(http.StatusUnprocessableEntity),
+		"field_errors": v.FieldErrors,
+	}
+
+	if len(v.NonFieldErrors) > 0 {
+		data["non_field_errors"] = v.NonFieldErrors
+	}
+
+	err := app.writeJSON(w, http.StatusUnprocessableEntity, data, nil)
+	if err != nil {
+		app.apiServerError(w, err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// NOTE: how often the failed attempts that fell out of the window are dropped
+const attemptsEvictInterval = time.Minute
+
+// NOTE: counts failed attempts (e.g. wrong snippet passwords) per key in memory. once a key has max failures inside
+// the window it's blocked until the oldest of them falls out of the window
+type failedAttempts struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	attempts map[string][]time.Time
+}
+
+func newFailedAttempts(max int, window time.Duration) *failedAttempts {
+	return &failedAttempts{
+		max:      max,
+		window:   window,
+		attempts: make(map[string][]time.Time),
+	}
+}
+
+// drops the failures that are older than the window (the caller must hold the lock)
+func (f *failedAttempts) prune(key string, now time.Time) []time.Time {
+	recent := f.attempts[key][:0]
+	for _, t := range f.attempts[key] {
+		if now.Sub(t) < f.window {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) == 0 {
+		delete(f.attempts, key)
+		return nil
+	}
+
+	f.attempts[key] = recent
+	return recent
+}
+
+func (f *failedAttempts) Blocked(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.prune(key, time.Now())) >= f.max
+}
+
+func (f *failedAttempts) Fail(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	f.attempts[key] = append(f.prune(key, now), now)
+}
+
+// Allow() counts an attempt for the key unless it's blocked already and reports whether it was counted. unlike
+// Blocked() followed by Fail() it's a single step, so a burst of concurrent attempts can't all get past the check
+func (f *failedAttempts) Allow(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+
+	recent := f.prune(key, now)
+	if len(recent) >= f.max {
+		return false
+	}
+
+	f.attempts[key] = append(recent, now)
+
+	return true
+}
+
+func (f *failedAttempts) Reset(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.attempts, key)
+}
+
+// drops the keys whose failures have all fallen out of the window and returns how many. the keys are only pruned when
+// they're used again otherwise, so the ones that are never used again would pile up
+func (f *failedAttempts) evict(now time.Time) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for key := range f.attempts {
+		if f.prune(key, now) == nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+// drops the stale failed attempts (of the snippet unlocks, the verification resends and the password reset requests)
+// every interval, until ctx is cancelled
+func (app *application) evictAttempts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, f := range []*failedAttempts{app.unlockAttempts, app.verificationResends, app.resetRequests} {
+				f.evict(now)
+			}
+		}
+	}
+}
+
+// returns the client's IP address without the port
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
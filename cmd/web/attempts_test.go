@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestFailedAttemptsEvict(t *testing.T) {
+	f := newFailedAttempts(3, time.Minute)
+
+	f.Fail("once")
+	f.Fail("twice")
+	f.Fail("twice")
+
+	now := time.Now()
+
+	assert.Equal(t, f.evict(now), 0)
+	assert.Equal(t, len(f.attempts), 2)
+
+	// NOTE: the failures are all outside the window by now, without either key being used again
+	assert.Equal(t, f.evict(now.Add(time.Minute)), 2)
+	assert.Equal(t, len(f.attempts), 0)
+	assert.Equal(t, f.Blocked("twice"), false)
+}
+
+func TestFailedAttemptsAllow(t *testing.T) {
+	f := newFailedAttempts(3, time.Minute)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if f.Allow("key") {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// NOTE: the check and the count are a single step, so no more than max get through at once
+	assert.Equal(t, allowed, 3)
+	assert.Equal(t, f.Blocked("key"), true)
+	assert.Equal(t, f.Blocked("other"), false)
+}
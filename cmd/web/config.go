@@ -0,0 +1,401 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-sql-driver/mysql"
+	"github.com/harshk200/snippetbox/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// NOTE: prefix of the environment variables, the rest is the flag name in upper case with '-' replaced by '_'
+// e.g. -shutdown-timeout is SNIPPETBOX_SHUTDOWN_TIMEOUT
+const envPrefix = "SNIPPETBOX_"
+
+const redacted = "REDACTED"
+
+// the values of -storage
+const (
+	storageSQL    = "sql"
+	storageMemory = "memory"
+)
+
+// NOTE: time.Duration with a text encoding so durations can be written as "12h" or "30s" in the config file
+type duration struct {
+	time.Duration
+}
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+// NOTE: the rate and burst of a rate limiter, written as "rate:burst" e.g. "5:20" is 5 requests per second with bursts
+// of up to 20
+type rateLimitConfig struct {
+	Rate  float64
+	Burst int
+}
+
+func (c rateLimitConfig) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatFloat(c.Rate, 'f', -1, 64) + ":" + strconv.Itoa(c.Burst)), nil
+}
+
+func (c *rateLimitConfig) UnmarshalText(text []byte) error {
+	rate, burst, found := strings.Cut(string(text), ":")
+	if !found {
+		return fmt.Errorf("%q is not rate:burst", text)
+	}
+
+	var err error
+
+	c.Rate, err = strconv.ParseFloat(rate, 64)
+	if err != nil {
+		return fmt.Errorf("invalid rate %q", rate)
+	}
+
+	c.Burst, err = strconv.Atoi(burst)
+	if err != nil {
+		return fmt.Errorf("invalid burst %q", burst)
+	}
+
+	return nil
+}
+
+func (c rateLimitConfig) valid() bool {
+	return c.Rate > 0 && c.Burst >= 1
+}
+
+// all the settings of the web app. the keys in the config file are the flag names with '-' replaced by '_'
+type config struct {
+	Addr            string   `json:"addr" toml:"addr" yaml:"addr"`
+	Storage         string   `json:"storage" toml:"storage" yaml:"storage"`
+	DNS             string   `json:"dns" toml:"dns" yaml:"dns"`
+	Secret          string   `json:"secret" toml:"secret" yaml:"secret"`
+	TLSCert         string   `json:"tls_cert" toml:"tls_cert" yaml:"tls_cert"`
+	TLSKey          string   `json:"tls_key" toml:"tls_key" yaml:"tls_key"`
+	SessionLifetime duration `json:"session_lifetime" toml:"session_lifetime" yaml:"session_lifetime"`
+	IdleTimeout     duration `json:"idle_timeout" toml:"idle_timeout" yaml:"idle_timeout"`
+	ReadTimeout     duration `json:"read_timeout" toml:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout    duration `json:"write_timeout" toml:"write_timeout" yaml:"write_timeout"`
+	ShutdownTimeout duration `json:"shutdown_timeout" toml:"shutdown_timeout" yaml:"shutdown_timeout"`
+	SweepInterval   duration `json:"sweep_interval" toml:"sweep_interval" yaml:"sweep_interval"`
+	ExpiredGrace    duration `json:"expired_grace" toml:"expired_grace" yaml:"expired_grace"`
+	BaseURL         string   `json:"base_url" toml:"base_url" yaml:"base_url"`
+	MailSender      string   `json:"mail_sender" toml:"mail_sender" yaml:"mail_sender"`
+	MailDir         string   `json:"mail_dir" toml:"mail_dir" yaml:"mail_dir"`
+	SMTPHost        string   `json:"smtp_host" toml:"smtp_host" yaml:"smtp_host"`
+	SMTPPort        int      `json:"smtp_port" toml:"smtp_port" yaml:"smtp_port"`
+	SMTPUsername    string   `json:"smtp_username" toml:"smtp_username" yaml:"smtp_username"`
+	SMTPPassword    string   `json:"smtp_password" toml:"smtp_password" yaml:"smtp_password"`
+
+	RateLimitIP        rateLimitConfig `json:"rate_limit_ip" toml:"rate_limit_ip" yaml:"rate_limit_ip"`
+	RateLimitDynamic   rateLimitConfig `json:"rate_limit_dynamic" toml:"rate_limit_dynamic" yaml:"rate_limit_dynamic"`
+	RateLimitProtected rateLimitConfig `json:"rate_limit_protected" toml:"rate_limit_protected" yaml:"rate_limit_protected"`
+	RateLimitStatic    rateLimitConfig `json:"rate_limit_static" toml:"rate_limit_static" yaml:"rate_limit_static"`
+
+	printConfig bool     // NOTE: set by -print-config, not part of the config itself
+	args        []string // NOTE: what's left after the flags i.e. the subcommand (if any)
+}
+
+func defaultConfig() *config {
+	return &config{
+		Addr:            ":3000",
+		Storage:         storageSQL,
+		DNS:             "web:password@/snippetbox?parseTime=true&interpolateParams=true",
+		TLSCert:         "./tls/cert.pem",
+		TLSKey:          "./tls/key.pem",
+		SessionLifetime: duration{12 * time.Hour},
+		IdleTimeout:     duration{time.Minute},
+		ReadTimeout:     duration{5 * time.Second},
+		WriteTimeout:    duration{10 * time.Second},
+		ShutdownTimeout: duration{30 * time.Second},
+		SweepInterval:   duration{5 * time.Minute},
+		ExpiredGrace:    duration{24 * time.Hour},
+		BaseURL:         "https://localhost:3000",
+		MailSender:      "Snippetbox <no-reply@snippetbox.local>",
+		MailDir:         "./tmp/mail",
+		SMTPPort:        587,
+
+		RateLimitIP:        rateLimitConfig{Rate: 20, Burst: 100},
+		RateLimitDynamic:   rateLimitConfig{Rate: 5, Burst: 20},
+		RateLimitProtected: rateLimitConfig{Rate: 2, Burst: 10},
+		RateLimitStatic:    rateLimitConfig{Rate: 20, Burst: 100},
+	}
+}
+
+// NOTE: binds the flags to the cfg fields, their defaults are whatever cfg currently holds
+func (cfg *config) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] [migrate up|down [n]|status]\n", name)
+		fs.PrintDefaults()
+	}
+
+	fs.String("config", "", "Path to a TOML, YAML or JSON config file (or "+envPrefix+"CONFIG)")
+	fs.BoolVar(&cfg.printConfig, "print-config", false, "Print the effective config (with the secrets redacted) and exit")
+
+	fs.StringVar(&cfg.Addr, "addr", cfg.Addr, "HTTP network address")
+	fs.StringVar(&cfg.Storage, "storage", cfg.Storage, "Where the data is kept: sql (the -dns database) or memory (lost on restart, for development)")
+	fs.StringVar(&cfg.DNS, "dns", cfg.DNS, "DSN of the database: a MySQL DSN, postgres://... or sqlite://path")
+	fs.StringVar(&cfg.Secret, "secret", cfg.Secret, "Secret key for signing pagination cursors and email verification links, changing it invalidates the ones already sent (required with -storage=sql, a random one is generated if empty with -storage=memory)")
+	fs.StringVar(&cfg.TLSCert, "tls-cert", cfg.TLSCert, "Path to the TLS certificate")
+	fs.StringVar(&cfg.TLSKey, "tls-key", cfg.TLSKey, "Path to the TLS private key")
+	fs.TextVar(&cfg.SessionLifetime, "session-lifetime", cfg.SessionLifetime, "How long a session lasts")
+	fs.TextVar(&cfg.IdleTimeout, "idle-timeout", cfg.IdleTimeout, "How long keep-alive connections are kept open while idle")
+	fs.TextVar(&cfg.ReadTimeout, "read-timeout", cfg.ReadTimeout, "Max duration for reading a request")
+	fs.TextVar(&cfg.WriteTimeout, "write-timeout", cfg.WriteTimeout, "Max duration for writing a response")
+	fs.TextVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "How long to wait for in-flight requests and background tasks when shutting down")
+	fs.TextVar(&cfg.SweepInterval, "sweep-interval", cfg.SweepInterval, "How often expired snippets and sessions are deleted (0 disables the sweeper)")
+	fs.TextVar(&cfg.ExpiredGrace, "expired-grace", cfg.ExpiredGrace, "How long expired snippets are kept (still shown to their owners) before the sweeper deletes them")
+	fs.StringVar(&cfg.BaseURL, "base-url", cfg.BaseURL, "Public URL of the app, used for the links in the emails")
+	fs.StringVar(&cfg.MailSender, "mail-sender", cfg.MailSender, "From address of the emails")
+	fs.StringVar(&cfg.MailDir, "mail-dir", cfg.MailDir, "Directory the emails are written to when there is no -smtp-host (for development)")
+	fs.StringVar(&cfg.SMTPHost, "smtp-host", cfg.SMTPHost, "SMTP server the emails are sent through (the emails go to -mail-dir if empty)")
+	fs.IntVar(&cfg.SMTPPort, "smtp-port", cfg.SMTPPort, "SMTP server port")
+	fs.StringVar(&cfg.SMTPUsername, "smtp-username", cfg.SMTPUsername, "SMTP username (no auth if empty)")
+	fs.StringVar(&cfg.SMTPPassword, "smtp-password", cfg.SMTPPassword, "SMTP password")
+	fs.TextVar(&cfg.RateLimitIP, "rate-limit-ip", cfg.RateLimitIP, "Requests per second:burst allowed per ip on all the pages and the api, checked before the session is loaded (should be above the other limits)")
+	fs.TextVar(&cfg.RateLimitDynamic, "rate-limit-dynamic", cfg.RateLimitDynamic, "Requests per second:burst allowed per client on the public pages")
+	fs.TextVar(&cfg.RateLimitProtected, "rate-limit-protected", cfg.RateLimitProtected, "Requests per second:burst allowed per user on the pages that need a login")
+	fs.TextVar(&cfg.RateLimitStatic, "rate-limit-static", cfg.RateLimitStatic, "Requests per second:burst allowed per client for the static files")
+
+	return fs
+}
+
+// builds the config from (lowest to highest precedence) the defaults, the config file, the SNIPPETBOX_*
+// environment variables and the command line flags.
+// NOTE: the result isn't validated yet so that -print-config can show an invalid config too
+func loadConfig(name string, args []string) (*config, error) {
+	cfg := defaultConfig()
+	fs := cfg.flagSet(name)
+
+	// NOTE: parsing the flags twice, first only to find the config file. parsing them again after the file and the
+	// environment variables were applied re-sets only the flags that were actually passed, so those win
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		path = os.Getenv(envPrefix + "CONFIG")
+	}
+
+	if path != "" {
+		err = cfg.loadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	err = cfg.loadEnv(fs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.args = fs.Args()
+
+	return cfg, nil
+}
+
+// NOTE: the format is picked by the file extension, unknown keys are an error (most likely a typo)
+func (cfg *config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		md, err := toml.NewDecoder(f).Decode(cfg)
+		if err != nil {
+			return err
+		}
+
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown key %q", undecoded[0].String())
+		}
+	case ".json":
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+
+		err = dec.Decode(cfg)
+		if err != nil {
+			return err
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+
+		// NOTE: an empty file is io.EOF, same as a toml file without any keys it just keeps the defaults
+		err = dec.Decode(cfg)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	default:
+		return errors.New("unsupported format (must be .toml, .yaml or .json)")
+	}
+
+	return nil
+}
+
+// applies the SNIPPETBOX_* environment variables through the flags (so they're parsed the same way)
+func (cfg *config) loadEnv(fs *flag.FlagSet) error {
+	var errs []error
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" || f.Name == "print-config" {
+			return
+		}
+
+		key := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			return
+		}
+
+		err := fs.Set(f.Name, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for %s: %w", value, key, err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// returns all the problems with the config at once
+func (cfg *config) validate() error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(cfg.Addr != "", "addr must be provided")
+	check(cfg.Storage == storageSQL || cfg.Storage == storageMemory, "storage must be %s or %s", storageSQL, storageMemory)
+	check(cfg.DNS != "" || cfg.Storage != storageSQL, "dns must be provided")
+
+	// NOTE: a random secret on every start would break the verification links sent before a restart. with the
+	// in-memory storage the users are gone after a restart anyway
+	check(cfg.Secret != "" || cfg.Storage != storageSQL, "secret must be provided")
+
+	_, err := os.Stat(cfg.TLSCert)
+	check(err == nil, "tls-cert: %v", err)
+
+	_, err = os.Stat(cfg.TLSKey)
+	check(err == nil, "tls-key: %v", err)
+
+	check(cfg.SessionLifetime.Duration > 0, "session-lifetime must be positive")
+	check(cfg.IdleTimeout.Duration > 0, "idle-timeout must be positive")
+	check(cfg.ReadTimeout.Duration > 0, "read-timeout must be positive")
+	check(cfg.WriteTimeout.Duration > 0, "write-timeout must be positive")
+	check(cfg.ShutdownTimeout.Duration > 0, "shutdown-timeout must be positive")
+	check(cfg.SweepInterval.Duration >= 0, "sweep-interval cannot be negative")
+	check(cfg.ExpiredGrace.Duration >= 0, "expired-grace cannot be negative")
+
+	// NOTE: the links in the emails are built from base-url and never from the request's Host header (which the
+	// client controls, so a reset link could be made to point somewhere else)
+	u, err := url.Parse(cfg.BaseURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "base-url must be an absolute http(s) URL")
+
+	_, err = mail.ParseAddress(cfg.MailSender)
+	check(err == nil, "mail-sender: %v", err)
+
+	check(cfg.SMTPHost != "" || cfg.MailDir != "", "mail-dir must be provided when there is no smtp-host")
+	check(cfg.SMTPHost == "" || (cfg.SMTPPort > 0 && cfg.SMTPPort <= 65535), "smtp-port must be between 1 and 65535")
+
+	check(cfg.RateLimitIP.valid(), "rate-limit-ip must have a positive rate and a burst of at least 1")
+	check(cfg.RateLimitDynamic.valid(), "rate-limit-dynamic must have a positive rate and a burst of at least 1")
+	check(cfg.RateLimitProtected.valid(), "rate-limit-protected must have a positive rate and a burst of at least 1")
+	check(cfg.RateLimitStatic.valid(), "rate-limit-static must have a positive rate and a burst of at least 1")
+
+	return errors.Join(errs...)
+}
+
+// returns a copy of the config that's safe to print i.e. the secret, the db password and the smtp password are redacted
+func (cfg *config) redacted() config {
+	c := *cfg
+
+	if c.Secret != "" {
+		c.Secret = redacted
+	}
+
+	c.DNS = redactDSN(c.DNS)
+
+	if c.SMTPPassword != "" {
+		c.SMTPPassword = redacted
+	}
+
+	return c
+}
+
+// NOTE: when it can't tell which part of the DSN is the password the whole DSN is redacted
+func redactDSN(dsn string) string {
+	dialect, source, err := models.ParseDSN(dsn)
+	if err != nil {
+		return redacted
+	}
+
+	switch dialect {
+	case models.Postgres:
+		u, err := url.Parse(source)
+		if err != nil {
+			return redacted
+		}
+
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+
+		return u.String()
+	case models.SQLite:
+		return dsn // NOTE: just a file path
+	default:
+		cfg, err := mysql.ParseDSN(source)
+		if err != nil {
+			return redacted
+		}
+
+		if cfg.Passwd != "" {
+			cfg.Passwd = redacted
+		}
+
+		return cfg.FormatDSN()
+	}
+}
+
+// writes the redacted config as json (which can be used as a config file as-is)
+func (cfg *config) print(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(cfg.redacted())
+}
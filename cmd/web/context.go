@@ -4,3 +4,8 @@ package main
 type contextKey string
 
 const isAuthenticatedContextKey = contextKey("isAuthenticated")
+
+const authenticatedUserIDContextKey = contextKey("authenticatedUserID")
+
+// NOTE: set when the request was authenticated with an api token (instead of the session cookie)
+const isTokenAuthenticatedContextKey = contextKey("isTokenAuthenticated")
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alexedwards/scs/mysqlstore"
+	"github.com/alexedwards/scs/pgxstore"
+	"github.com/alexedwards/scs/sqlite3store"
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-sql-driver/mysql"
+	"github.com/harshk200/snippetbox/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// the connection pool together with the dialect it speaks
+type database struct {
+	*sql.DB
+	dialect models.Dialect
+	pool    *pgxpool.Pool // NOTE: only for postgres, the *sql.DB wraps it (and pgxstore wants the pool itself)
+}
+
+// opens the database picked by the DSN's scheme (see models.ParseDSN). multiStatements lets a single Exec run a
+// whole script (only mysql needs to be told, used by the migrations)
+func openDB(dsn string, multiStatements bool) (*database, error) {
+	dialect, source, err := models.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &database{dialect: dialect}
+
+	// NOTE: this db here is not a connection it's a connection pool (i.e. no connections are made yet but when they do this will hold them)
+	// also this is concurrent safe
+	switch dialect {
+	case models.Postgres:
+		db.pool, err = pgxpool.New(context.Background(), source)
+		if err != nil {
+			return nil, err
+		}
+
+		db.DB = stdlib.OpenDBFromPool(db.pool)
+	case models.SQLite:
+		db.DB, err = sql.Open("sqlite", source)
+		if err != nil {
+			return nil, err
+		}
+
+		// NOTE: sqlite only has one writer at a time anyway and every connection to :memory: would get it's own db
+		db.SetMaxOpenConns(1)
+	default:
+		if multiStatements {
+			cfg, err := mysql.ParseDSN(source)
+			if err != nil {
+				return nil, err
+			}
+
+			cfg.MultiStatements = true
+			source = cfg.FormatDSN()
+		}
+
+		db.DB, err = sql.Open("mysql", source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// NOTE: just testing if the connection to db is good or not?
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (db *database) Close() error {
+	err := db.DB.Close()
+
+	if db.pool != nil {
+		db.pool.Close()
+	}
+
+	return err
+}
+
+// returns the scs store that keeps the sessions in this database.
+// NOTE: the stores' own cleanup is disabled as the sweeper deletes the expired sessions
+func (db *database) sessionStore() scs.Store {
+	switch db.dialect {
+	case models.Postgres:
+		return pgxstore.NewWithCleanupInterval(db.pool, 0)
+	case models.SQLite:
+		return sqlite3store.NewWithCleanupInterval(db.DB, 0)
+	default:
+		return mysqlstore.NewWithCleanupInterval(db.DB, 0)
+	}
+}
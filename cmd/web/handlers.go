@@ -1,11 +1,21 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
+	"mime"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/harshk200/snippetbox/internal/diff"
 	"github.com/harshk200/snippetbox/internal/models"
 	"github.com/harshk200/snippetbox/internal/validator"
 	"github.com/julienschmidt/httprouter"
@@ -27,79 +37,993 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 	app.render(w, http.StatusOK, "home.tmpl", data)
 }
 
+const archiveSnippetsPerPage = 20
+
+// NOTE: keyset paginated listing of all the unexpired snippets. ?after=<cursor> goes to older snippets and
+// ?before=<cursor> back to newer ones
+func (app *application) snippetArchive(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var snippets []*models.Snippet
+	var page pagination
+	var err error
+
+	if before := query.Get("before"); before != "" {
+		id, err := app.decodeCursor(before)
+		if err != nil {
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+
+		// NOTE: fetching one extra row to know if there is a previous page
+		snippets, err = app.snippetModel.ListAfter(id, archiveSnippetsPerPage+1)
+		if err != nil {
+			app.serverError(w, err)
+			return
+		}
+
+		if len(snippets) > archiveSnippetsPerPage {
+			snippets = snippets[1:]
+			page.PrevCursor = app.encodeCursor(snippets[0].ID)
+		}
+
+		if len(snippets) > 0 {
+			page.NextCursor = app.encodeCursor(snippets[len(snippets)-1].ID)
+		}
+	} else {
+		id := 0
+		if after := query.Get("after"); after != "" {
+			id, err = app.decodeCursor(after)
+			if err != nil {
+				app.clientError(w, http.StatusBadRequest)
+				return
+			}
+		}
+
+		snippets, err = app.snippetModel.ListBefore(id, archiveSnippetsPerPage+1)
+		if err != nil {
+			app.serverError(w, err)
+			return
+		}
+
+		if len(snippets) > archiveSnippetsPerPage {
+			snippets = snippets[:archiveSnippetsPerPage]
+			page.NextCursor = app.encodeCursor(snippets[len(snippets)-1].ID)
+		}
+
+		if id != 0 && len(snippets) > 0 {
+			page.PrevCursor = app.encodeCursor(snippets[0].ID)
+		}
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippets = snippets
+	data.Pagination = page
+	app.render(w, http.StatusOK, "archive.tmpl", data)
+}
+
+// NOTE: the :id param is either the numeric ID (public snippets) or the random slug (unlisted snippets)
 func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	id, err := strconv.Atoi(params.ByName("id"))
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	// NOTE: password protected snippets show the unlock form until the right password was entered in this session
+	if !app.isUnlocked(r, snippet) {
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+		data.Form = snippetUnlockFormData{Ref: httprouter.ParamsFromContext(r.Context()).ByName("id")}
+		app.render(w, http.StatusOK, "unlock.tmpl", data)
+		return
+	}
+
+	snippet, err := app.burnIfNeeded(r, snippet)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w) // NOTE: someone else read (and burned) it first
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	forkedFrom, err := app.forkedFrom(r, snippet)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	forks, err := app.visibleForks(r, snippet)
 	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.ForkedFrom = forkedFrom
+	data.ForkCount = len(forks)
+
+	app.render(w, http.StatusOK, "view.tmpl", data)
+}
+
+// NOTE: lists the forks of the snippet the current user can see
+func (app *application) snippetForks(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	forks, err := app.visibleForks(r, snippet)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Snippets = forks
+
+	app.render(w, http.StatusOK, "forks.tmpl", data)
+}
+
+// NOTE: the bare content for curl and wget, with the same visibility, expiry and burn after reading rules as
+// snippetView. a password protected snippet has to be unlocked in the browser first (403 otherwise)
+func (app *application) snippetRaw(w http.ResponseWriter, r *http.Request) {
+	app.serveSnippetContent(w, r, false)
+}
+
+// NOTE: same as snippetRaw but saved as a file named after the title and language
+func (app *application) snippetDownload(w http.ResponseWriter, r *http.Request) {
+	app.serveSnippetContent(w, r, true)
+}
+
+func (app *application) serveSnippetContent(w http.ResponseWriter, r *http.Request, attachment bool) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	if !app.isUnlocked(r, snippet) {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	snippet, err := app.burnIfNeeded(r, snippet)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", contentCacheControl(snippet))
+
+	if attachment {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": snippetFilename(snippet)}))
+	}
+
+	// NOTE: the burn after reading ones are already deleted at this point so they're always sent in full, ServeContent
+	// would answer a Range request with just a part of it or an If-None-Match with a 304 and no body at all
+	if snippet.BurnAfterReading {
+		w.Header().Set("Content-Length", strconv.Itoa(len(snippet.Content)))
+		w.Write([]byte(snippet.Content))
+		return
+	}
+
+	w.Header().Set("ETag", contentETag(snippet))
+
+	// NOTE: takes care of If-None-Match (304) and Range requests
+	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(snippet.Content))
+}
+
+// NOTE: how long a public snippet may be cached for, an edit can take this long to show up
+const contentMaxAge = 5 * time.Minute
+
+// NOTE: only public snippets can be kept by shared caches and only until they expire. the rest depend on who's asking
+// so they have to be revalidated every time, and the burn after reading ones can't be stored at all
+func contentCacheControl(snippet *models.Snippet) string {
+	switch {
+	case snippet.BurnAfterReading:
+		return "no-store"
+	case snippet.Visibility == models.VisibilityPublic && !snippet.IsProtected():
+		maxAge := contentMaxAge
+		if !snippet.NeverExpires() {
+			maxAge = max(min(maxAge, time.Until(snippet.Expires)), 0)
+		}
+
+		return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	default:
+		return "private, no-cache"
+	}
+}
+
+// NOTE: changes whenever the content or the download's filename would
+func contentETag(snippet *models.Snippet) string {
+	hash := sha256.Sum256([]byte(snippet.Title + "\x00" + snippet.Language + "\x00" + snippet.Content))
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+var filenameRX = regexp.MustCompile(`[^a-z0-9]+`)
+
+// returns the title turned into a safe file name (e.g. "Hello, World!" in go is hello-world.go)
+func snippetFilename(snippet *models.Snippet) string {
+	name := strings.Trim(filenameRX.ReplaceAllString(strings.ToLower(snippet.Title), "-"), "-")
+	if len(name) > 50 {
+		name = strings.TrimRight(name[:50], "-")
+	}
+
+	// NOTE: a title without any ascii letters or digits
+	if name == "" {
+		name = fmt.Sprintf("snippet-%d", snippet.ID)
+	}
+
+	return name + languageExtension(snippet.Language)
+}
+
+type snippetUnlockFormData struct {
+	Password            string `form:"password"`
+	Ref                 string `form:"-"` // NOTE: the ID or slug the snippet was opened with
+	validator.Validator `form:"-"`
+}
+
+func (app *application) snippetUnlockPost(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	var formData snippetUnlockFormData
+	err := app.decodePostForm(r, &formData)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	formData.Ref = httprouter.ParamsFromContext(r.Context()).ByName("id")
+
+	// NOTE: failed attempts are counted per client and snippet
+	attemptsKey := fmt.Sprintf("%s:%d", clientIP(r), snippet.ID)
+
+	if app.unlockAttempts.Blocked(attemptsKey) {
+		formData.AddNonFieldError("Too many failed attempts, please try again later")
+
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+		data.Form = formData
+		app.render(w, http.StatusTooManyRequests, "unlock.tmpl", data)
+		return
+	}
+
+	matches, err := snippet.PasswordMatches(formData.Password)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	if !matches {
+		app.unlockAttempts.Fail(attemptsKey)
+		formData.AddNonFieldError("Incorrect password")
+
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+		data.Form = formData
+		app.render(w, http.StatusUnprocessableEntity, "unlock.tmpl", data)
+		return
+	}
+
+	app.unlockAttempts.Reset(attemptsKey)
+	app.sessionManager.Put(r.Context(), unlockedSnippetKey(snippet.ID), true)
+
+	http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+}
+
+const searchResultsPerPage = 10
+
+type snippetSearchFormData struct {
+	Query               string `form:"q"`
+	Page                int    `form:"page"`
+	PrevPage            int    `form:"-"` // NOTE: 0 means there is no such page
+	NextPage            int    `form:"-"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) snippetSearch(w http.ResponseWriter, r *http.Request) {
+	var formData snippetSearchFormData
+	err := app.formDecoder.Decode(&formData, r.URL.Query())
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if formData.Page < 1 {
+		formData.Page = 1
+	}
+
+	// NOTE: a page that big would overflow the offset below
+	if formData.Page > math.MaxInt/searchResultsPerPage {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	data := app.newTemplateData(r)
+
+	// NOTE: an empty query just renders the search form
+	if !validator.NotBlank(formData.Query) {
+		data.Form = formData
+		app.render(w, http.StatusOK, "search.tmpl", data)
+		return
+	}
+
+	formData.CheckField(validator.MaxChars(formData.Query, 100), "q", "This field cannot be more than 100 characters long")
+
+	if !formData.Valid() {
+		data.Form = formData
+		app.render(w, http.StatusUnprocessableEntity, "search.tmpl", data)
+		return
+	}
+
+	// NOTE: fetching one extra row to know if there is a next page
+	snippets, err := app.snippetModel.Search(formData.Query, searchResultsPerPage+1, (formData.Page-1)*searchResultsPerPage)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	if len(snippets) > searchResultsPerPage {
+		snippets = snippets[:searchResultsPerPage]
+		formData.NextPage = formData.Page + 1
+	}
+
+	if formData.Page > 1 {
+		formData.PrevPage = formData.Page - 1
+	}
+
+	data.Snippets = snippets
+	data.Form = formData
+	app.render(w, http.StatusOK, "search.tmpl", data)
+}
+
+// NOTE: ?fork=<id or slug> pre-fills the form with a copy of that snippet
+func (app *application) snippetCreate(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	form := newSnippetCreateFormData()
+
+	if ref := r.URL.Query().Get("fork"); ref != "" {
+		source, err := app.forkableSnippet(r, ref)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				app.notFound(w)
+			} else {
+				app.serverError(w, err)
+			}
+
+			return
+		}
+
+		// NOTE: the password and burn after reading aren't copied, the fork is the user's own snippet
+		form.Fork = ref
+		form.Title = source.Title
+		form.Content = source.Content
+		form.Language = source.Language
+		form.Visibility = source.Visibility
+		data.ForkedFrom = source
+	}
+
+	data.Form = form
+
+	app.render(w, http.StatusOK, "create.tmpl", data)
+}
+
+// NOTE: not having a property instead embedding the validator here i.e. the snippetCreateFormData struct inherits from the validator
+// NOTE: the json tags are used when the api decodes the request-body into this struct
+type snippetCreateFormData struct {
+	Title               string `form:"title" json:"title"`
+	Content             string `form:"content" json:"content"`
+	Language            string `form:"language" json:"language"`
+	Visibility          string `form:"visibility" json:"visibility"`
+	Password            string `form:"password" json:"password"` // NOTE: optional, empty means not protected
+	BurnAfterReading    bool   `form:"burn_after_reading" json:"burn_after_reading"`
+	Expires             string `form:"expires" json:"expires"` // NOTE: one of expiresIn, expiresAt or expiresNever
+	ExpiresIn           int    `form:"expires_in" json:"expires_in"`
+	ExpiresUnit         string `form:"expires_unit" json:"expires_unit"`
+	ExpiresAt           string `form:"expires_at" json:"expires_at"`
+	Fork                string `form:"fork" json:"-"` // NOTE: the ID or slug of the snippet being forked (if any)
+	validator.Validator `form:"-" json:"-"`
+
+	currentExpires time.Time // NOTE: the expiry of the snippet being edited (zero otherwise), see keepsExpiry()
+}
+
+// NOTE: form data validation (shared by the create and the edit handlers)
+func (form *snippetCreateFormData) validate() {
+	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
+	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	form.CheckField(validator.PermittedValue(form.Language, supportedLanguageValues...), "language", "This field must be one of the supported languages")
+	form.CheckField(validator.PermittedValue(form.Visibility, models.VisibilityPublic, models.VisibilityUnlisted, models.VisibilityPrivate), "visibility", "This field can only be public, unlisted or private")
+	form.CheckField(validator.MaxChars(form.Password, 72), "password", "This field cannot be more than 72 characters long")
+	form.CheckField(validator.PermittedValue(form.Expires, expiresIn, expiresAt, expiresNever), "expires", "This field can only be in, at or never")
+
+	switch form.Expires {
+	case expiresIn:
+		unit, ok := expiresUnits[form.ExpiresUnit]
+		form.CheckField(ok, "expires", "The unit can only be minutes, hours or days")
+		form.CheckField(form.ExpiresIn >= 1, "expires", "This field must be a positive number")
+		// NOTE: dividing instead of multiplying, a big enough number of days overflows the duration
+		form.CheckField(!ok || form.ExpiresIn <= int(maxExpiry/unit), "expires", "This field cannot be more than 365 days")
+	case expiresAt:
+		// NOTE: the unchanged pre-fill of the edit form keeps the current expiry instead of being checked again, it's
+		// only to the minute so it could be in the past already (and would cut the expiry short anyway)
+		if form.keepsExpiry() {
+			form.CheckField(time.Until(form.currentExpires) >= time.Second, "expires", "This field must be in the future")
+			break
+		}
+
+		t, err := parseExpiresAt(form.ExpiresAt)
+		form.CheckField(err == nil, "expires", "This field must be a valid date and time")
+		form.CheckField(err != nil || time.Now().Before(t), "expires", "This field must be in the future")
+		form.CheckField(err != nil || time.Until(t) <= maxExpiry, "expires", "This field cannot be more than 365 days from now")
+	}
+}
+
+// NOTE: the expiry can be given relative to now, as an absolute date and time or not at all
+const (
+	expiresIn    = "in"
+	expiresAt    = "at"
+	expiresNever = "never"
+)
+
+const maxExpiry = 365 * 24 * time.Hour
+
+var expiresUnits = map[string]time.Duration{
+	"minutes": time.Minute,
+	"hours":   time.Hour,
+	"days":    24 * time.Hour,
+}
+
+// the value format of <input type="datetime-local">
+const datetimeLocalLayout = "2006-01-02T15:04"
+
+// parses the absolute expiry time, the date picker doesn't send a timezone so it's taken as UTC (api clients can
+// send RFC3339 instead)
+func parseExpiresAt(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation(datetimeLocalLayout, value, time.UTC)
+}
+
+// reports whether the expiry is the one the edit form was pre-filled with i.e. the snippet keeps its current expiry
+func (form *snippetCreateFormData) keepsExpiry() bool {
+	return form.Expires == expiresAt && !form.currentExpires.IsZero() &&
+		form.ExpiresAt == form.currentExpires.UTC().Format(datetimeLocalLayout)
+}
+
+// returns how long from now the snippet should live for (0 means it never expires). only call it on a valid form
+// (validate keeps the number in range, so the multiplication can't overflow)
+func (form *snippetCreateFormData) expiresDuration() time.Duration {
+	switch form.Expires {
+	case expiresIn:
+		return time.Duration(form.ExpiresIn) * expiresUnits[form.ExpiresUnit]
+	case expiresAt:
+		if form.keepsExpiry() {
+			// NOTE: whole seconds from the start of this second (like the models' now()) so the stored expiry comes
+			// out the same. at least a second as 0 would mean never
+			return max(form.currentExpires.Sub(time.Now().Truncate(time.Second)), time.Second)
+		}
+
+		t, _ := parseExpiresAt(form.ExpiresAt)
+		return time.Until(t)
+	default:
+		return 0
+	}
+}
+
+// the create form's defaults (expires in a year like it always did)
+func newSnippetCreateFormData() *snippetCreateFormData {
+	return &snippetCreateFormData{
+		Language:    "plaintext",
+		Visibility:  models.VisibilityPublic,
+		Expires:     expiresIn,
+		ExpiresIn:   365,
+		ExpiresUnit: "days",
+	}
+}
+
+func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request) {
+	var formData snippetCreateFormData
+	err := app.decodePostForm(r, &formData)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	formData.validate()
+
+	// NOTE: checked again as the original could have changed (or been deleted) since the form was opened
+	var source *models.Snippet
+	if formData.Fork != "" {
+		source, err = app.forkableSnippet(r, formData.Fork)
+		if err != nil && !errors.Is(err, models.ErrNoRecord) {
+			app.serverError(w, err)
+			return
+		}
+
+		if source == nil {
+			formData.AddNonFieldError("The snippet you are forking doesn't exist anymore")
+		}
+	}
+
+	// NOTE: if any errors re-render the form
+	if !formData.Valid() {
+		formData.Password = ""
+
+		data := app.newTemplateData(r)
+		data.Form = formData
+		data.ForkedFrom = source
+		app.render(w, http.StatusUnprocessableEntity, "create.tmpl", data)
+		return
+	}
+
+	forkedFrom := 0
+	if source != nil {
+		forkedFrom = source.ID
+	}
+
+	id, err := app.snippetModel.Insert(formData.Title, formData.Content, formData.Language, formData.Visibility, formData.Password, formData.BurnAfterReading, formData.expiresDuration(), app.authenticatedUserID(r), forkedFrom)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	// NOTE: after the session data is created succesfully we put that info in the session manager
+	if formData.BurnAfterReading {
+		app.sessionManager.Put(r.Context(), "flash", "Snippet created succesfully! The link is one-time only, the snippet gets deleted as soon as someone opens it.")
+	} else {
+		app.sessionManager.Put(r.Context(), "flash", "Snippet created succesfully!")
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
+}
+
+func (app *application) snippetEdit(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.ownedSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	form := newSnippetCreateFormData()
+	form.Title = snippet.Title
+	form.Content = snippet.Content
+	form.Language = snippet.Language
+	form.Visibility = snippet.Visibility
+	form.BurnAfterReading = snippet.BurnAfterReading
+
+	// NOTE: keeps the current expiry unless the owner changes it
+	if snippet.NeverExpires() {
+		form.Expires = expiresNever
+	} else {
+		form.Expires = expiresAt
+		form.ExpiresAt = snippet.Expires.UTC().Format(datetimeLocalLayout)
+	}
+
+	data.Form = form
+
+	app.render(w, http.StatusOK, "edit.tmpl", data)
+}
+
+func (app *application) snippetEditPost(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.ownedSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	var formData snippetCreateFormData
+	err := app.decodePostForm(r, &formData)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if !snippet.NeverExpires() {
+		formData.currentExpires = snippet.Expires
+	}
+
+	formData.validate()
+
+	if !formData.Valid() {
+		formData.Password = ""
+
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+		data.Form = formData
+		app.render(w, http.StatusUnprocessableEntity, "edit.tmpl", data)
+		return
+	}
+
+	err = app.snippetModel.Update(snippet.ID, formData.Title, formData.Content, formData.Language, formData.Visibility, formData.Password, formData.BurnAfterReading, formData.expiresDuration())
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet updated succesfully!")
+
+	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%d", snippet.ID), http.StatusSeeOther)
+}
+
+func (app *application) snippetDeletePost(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.ownedSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.snippetModel.Delete(snippet.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet deleted succesfully!")
+
+	http.Redirect(w, r, "/user/snippets", http.StatusSeeOther)
+}
+
+// NOTE: lists every revision of the snippet with it's author, the :id param works the same as for snippetView
+func (app *application) snippetHistory(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.historySnippet(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := app.snippetModel.Revisions(snippet.ID)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Revisions = revisions
+
+	app.render(w, http.StatusOK, "history.tmpl", data)
+}
+
+// the two revisions being compared and the hunks turning the content of From into the content of To
+type revisionDiff struct {
+	From  *models.Revision
+	To    *models.Revision
+	Hunks []diff.Hunk
+}
+
+// NOTE: compares the revisions with the IDs in the from and to query params (any two of the snippet's revisions,
+// either way round). to defaults to the latest revision and from to the revision before to
+func (app *application) snippetDiff(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.historySnippet(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := app.snippetModel.Revisions(snippet.ID)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	if len(revisions) == 0 {
 		app.notFound(w)
 		return
 	}
 
-	snippet, err := app.snippetModel.Get(id)
+	query := r.URL.Query()
+
+	to := 0 // NOTE: indexes into revisions, which are newest first
+	if query.Has("to") {
+		to = findRevision(revisions, query.Get("to"))
+	}
+
+	from := min(to+1, len(revisions)-1)
+	if query.Has("from") {
+		from = findRevision(revisions, query.Get("from"))
+	}
+
+	if from == -1 || to == -1 {
+		app.notFound(w)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Revisions = revisions
+	data.Diff = &revisionDiff{
+		From:  revisions[from],
+		To:    revisions[to],
+		Hunks: diff.Hunks(revisions[from].Content, revisions[to].Content, 3),
+	}
+
+	app.render(w, http.StatusOK, "diff.tmpl", data)
+}
+
+// returns the index of the revision with the ID or -1 if it isn't one of them
+func findRevision(revisions []*models.Revision, id string) int {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return -1
+	}
+
+	return slices.IndexFunc(revisions, func(revision *models.Revision) bool {
+		return revision.ID == n
+	})
+}
+
+type snippetRestoreFormData struct {
+	Revision int `form:"revision"`
+}
+
+// NOTE: restoring records the old version as a new revision so nothing in the history is lost
+func (app *application) snippetRestorePost(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.ownedSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	var formData snippetRestoreFormData
+	err := app.decodePostForm(r, &formData)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.snippetModel.Restore(snippet.ID, formData.Revision)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Revision restored succesfully!")
+
+	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%d", snippet.ID), http.StatusSeeOther)
+}
+
+// NOTE: lists all the snippets created by the logged in user (the expired ones too)
+func (app *application) userSnippets(w http.ResponseWriter, r *http.Request) {
+	snippets, err := app.snippetModel.ByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippets = snippets
+	app.render(w, http.StatusOK, "userSnippets.tmpl", data)
+}
+
+// NOTE: the logged in user's own details
+func (app *application) accountView(w http.ResponseWriter, r *http.Request) {
+	user, err := app.userModel.Get(app.authenticatedUserID(r))
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
-			return
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
 		} else {
 			app.serverError(w, err)
-			return
 		}
+
+		return
 	}
 
 	data := app.newTemplateData(r)
-	data.Snippet = snippet
+	data.User = user
 
-	app.render(w, http.StatusOK, "view.tmpl", data)
+	app.render(w, http.StatusOK, "account.tmpl", data)
 }
 
-func (app *application) snippetCreate(w http.ResponseWriter, r *http.Request) {
+// NOTE: asks for another verification email, limited to a few per hour for each user
+func (app *application) accountVerificationResendPost(w http.ResponseWriter, r *http.Request) {
+	user, err := app.userModel.Get(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	if user.Verified {
+		app.sessionManager.Put(r.Context(), "flash", "Your email address is already verified.")
+		http.Redirect(w, r, "/account/view", http.StatusSeeOther)
+		return
+	}
+
+	key := strconv.Itoa(user.ID)
+
+	if app.verificationResends.Blocked(key) {
+		form := validator.Validator{}
+		form.AddNonFieldError("Too many verification emails, please wait a while before asking for another one.")
+
+		data := app.newTemplateData(r)
+		data.User = user
+		data.Form = form
+		app.render(w, http.StatusTooManyRequests, "account.tmpl", data)
+		return
+	}
+
+	// NOTE: counts the emails sent rather than failures
+	app.verificationResends.Fail(key)
+
+	app.background(func() {
+		app.sendVerificationMail(user)
+	})
+
+	app.sessionManager.Put(r.Context(), "flash", "We've sent you a new verification email.")
+
+	http.Redirect(w, r, "/account/view", http.StatusSeeOther)
+}
+
+type accountPasswordUpdateFormData struct {
+	CurrentPassword         string `form:"currentPassword"`
+	NewPassword             string `form:"newPassword"`
+	NewPasswordConfirmation string `form:"newPasswordConfirmation"`
+	validator.Validator     `form:"-"`
+}
+
+// NOTE: the rules for a new password, shared by the password change and the password reset forms
+func checkNewPassword(v *validator.Validator, password, confirmation string) {
+	v.CheckField(validator.NotBlank(password), "newPassword", "This field cannot be blank")
+	v.CheckField(validator.MinChars(password, 8), "newPassword", "password must be at least 8 characters long")
+	v.CheckField(validator.NotBlank(confirmation), "newPasswordConfirmation", "This field cannot be blank")
+	v.CheckField(password == confirmation, "newPasswordConfirmation", "Passwords do not match")
+}
+
+func (app *application) accountPasswordUpdate(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
-	form := &snippetCreateFormData{Expires: 365}
-	data.Form = form
+	data.Form = accountPasswordUpdateFormData{}
 
-	app.render(w, http.StatusOK, "create.tmpl", data)
+	app.render(w, http.StatusOK, "password.tmpl", data)
 }
 
-// NOTE: not having a property instead embedding the validator here i.e. the snippetCreateFormData struct inherits from the validator
-type snippetCreateFormData struct {
-	Title               string `form:"title"`
-	Content             string `form:"content"`
-	Expires             int    `form:"expires"`
+func (app *application) accountPasswordUpdatePost(w http.ResponseWriter, r *http.Request) {
+	var formData accountPasswordUpdateFormData
+	err := app.decodePostForm(r, &formData)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	formData.CheckField(validator.NotBlank(formData.CurrentPassword), "currentPassword", "This field cannot be blank")
+	checkNewPassword(&formData.Validator, formData.NewPassword, formData.NewPasswordConfirmation)
+
+	if !formData.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = formData
+		app.render(w, http.StatusUnprocessableEntity, "password.tmpl", data)
+		return
+	}
+
+	err = app.userModel.PasswordUpdate(app.authenticatedUserID(r), formData.CurrentPassword, formData.NewPassword)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			formData.AddFieldError("currentPassword", "Current password is incorrect")
+
+			data := app.newTemplateData(r)
+			data.Form = formData
+			app.render(w, http.StatusUnprocessableEntity, "password.tmpl", data)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	// NOTE: a new session token so a session id captured before the change is no good anymore
+	err = app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Your password has been updated succesfully!")
+
+	http.Redirect(w, r, "/account/view", http.StatusSeeOther)
+}
+
+type apiTokenCreateFormData struct {
+	Name                string `form:"name"`
 	validator.Validator `form:"-"`
 }
 
-func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request) {
-	var formData snippetCreateFormData
+// NOTE: settings page for managing the personal api tokens of the logged in user
+func (app *application) accountTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := app.apiTokenModel.ByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.APITokens = tokens
+	data.NewAPIToken = app.sessionManager.PopString(r.Context(), "newAPIToken") // NOTE: only shown once right after creation
+	data.Form = apiTokenCreateFormData{}
+
+	app.render(w, http.StatusOK, "tokens.tmpl", data)
+}
+
+func (app *application) accountTokensPost(w http.ResponseWriter, r *http.Request) {
+	var formData apiTokenCreateFormData
 	err := app.decodePostForm(r, &formData)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
 		return
 	}
 
-	// NOTE: form data validation
-	formData.CheckField(validator.NotBlank(formData.Title), "title", "This field cannot be blank")
-	formData.CheckField(validator.MaxChars(formData.Title, 100), "title", "This field cannot be more than 100 characters long")
-	formData.CheckField(validator.NotBlank(formData.Content), "content", "This field cannot be blank")
-	formData.CheckField(validator.PermittedValue(formData.Expires, 1, 7, 365), "expires", "This field can only be 1, 7 or 365")
+	formData.CheckField(validator.NotBlank(formData.Name), "name", "This field cannot be blank")
+	formData.CheckField(validator.MaxChars(formData.Name, 100), "name", "This field cannot be more than 100 characters long")
+
+	userID := app.authenticatedUserID(r)
 
-	// NOTE: if any errors re-render the form
 	if !formData.Valid() {
+		tokens, err := app.apiTokenModel.ByUser(userID)
+		if err != nil {
+			app.serverError(w, err)
+			return
+		}
+
 		data := app.newTemplateData(r)
+		data.APITokens = tokens
 		data.Form = formData
-		app.render(w, http.StatusUnprocessableEntity, "create.tmpl", data)
+		app.render(w, http.StatusUnprocessableEntity, "tokens.tmpl", data)
 		return
 	}
 
-	id, err := app.snippetModel.Insert(formData.Title, formData.Content, formData.Expires)
+	token, err := app.apiTokenModel.Insert(userID, formData.Name)
 	if err != nil {
 		app.serverError(w, err)
 		return
 	}
 
-	// NOTE: after the session data is created succesfully we put that info in the session manager
-	app.sessionManager.Put(r.Context(), "flash", "Snippet created succesfully!")
+	app.sessionManager.Put(r.Context(), "newAPIToken", token)
+	app.sessionManager.Put(r.Context(), "flash", "API token created succesfully! Copy it now, it won't be shown again.")
 
-	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
+
+func (app *application) accountTokenDeletePost(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w)
+		return
+	}
+
+	err = app.apiTokenModel.Delete(id, app.authenticatedUserID(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "API token revoked succesfully!")
+
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
 }
 
 type userSignupFormData struct {
@@ -138,7 +1062,7 @@ func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// NOTE: create new user...
-	err = app.userModel.Insert(formData.Name, formData.Email, formData.Password)
+	id, err := app.userModel.Insert(formData.Name, formData.Email, formData.Password)
 	if err != nil {
 		if errors.Is(err, models.ErrDuplicateEmail) {
 			formData.AddFieldError("email", "Email address is already in use")
@@ -153,7 +1077,13 @@ func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "flash", "Your signup was successful. Please login.")
+	user := &models.User{ID: id, Name: formData.Name, Email: formData.Email}
+
+	app.background(func() {
+		app.sendVerificationMail(user)
+	})
+
+	app.sessionManager.Put(r.Context(), "flash", "Your signup was successful. We've sent you an email to verify your address, please login.")
 
 	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
 }
@@ -186,8 +1116,32 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	keys := loginThrottleKeys(clientIP(r), formData.Email)
+
+	wait, locked, err := app.loginThrottle.Attempt(keys...)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	if wait > 0 {
+		if locked {
+			formData.AddNonFieldError(fmt.Sprintf("Too many failed login attempts, logging in is locked for %s.", formatWait(wait)))
+		} else {
+			formData.AddNonFieldError(fmt.Sprintf("Too many failed login attempts, please wait %s before trying again.", formatWait(wait)))
+		}
+
+		data := app.newTemplateData(r)
+		data.Form = formData
+
+		w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+		app.render(w, http.StatusTooManyRequests, "login.tmpl", data)
+		return
+	}
+
 	userID, err := app.userModel.Authenticate(formData.Email, formData.Password)
 	if err != nil {
+		// NOTE: the attempt was already counted as a failure by Attempt()
 		if errors.Is(err, models.ErrInvalidCredentials) {
 			formData.AddNonFieldError("Email or password is incorrect")
 			data := app.newTemplateData(r)
@@ -198,6 +1152,21 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 		}
 
 		app.serverError(w, err)
+		return
+	}
+
+	// NOTE: the email's failures are all forgotten but the ip only gets this attempt back, its earlier failures are
+	// kept so logging in to an account of your own in between doesn't reset the guessing of someone else's password
+	err = app.loginThrottle.Reset(keys[1])
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	err = app.loginThrottle.Forgive(keys[0])
+	if err != nil {
+		app.serverError(w, err)
+		return
 	}
 
 	err = app.sessionManager.RenewToken(r.Context())
@@ -225,3 +1194,192 @@ func (app *application) userLogout(w http.ResponseWriter, r *http.Request) {
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
+
+// NOTE: how long a password reset link works for (the email says "an hour" so change them together)
+const passwordResetTTL = time.Hour
+
+type userPasswordForgotFormData struct {
+	Email               string `form:"email"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) userPasswordForgot(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Form = userPasswordForgotFormData{}
+
+	app.render(w, http.StatusOK, "forgotPassword.tmpl", data)
+}
+
+func (app *application) userPasswordForgotPost(w http.ResponseWriter, r *http.Request) {
+	var formData userPasswordForgotFormData
+	err := app.decodePostForm(r, &formData)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	formData.CheckField(validator.NotBlank(formData.Email), "email", "This field cannot be blank")
+	formData.CheckField(validator.Matches(formData.Email, validator.EmailRX), "email", "This field must be a valid email address")
+
+	if !formData.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = formData
+		app.render(w, http.StatusUnprocessableEntity, "forgotPassword.tmpl", data)
+		return
+	}
+
+	// NOTE: done in the background so the response (and how long it takes) is the same whether or not there is an
+	// account with the email, otherwise this form could be used to find out who has one
+	email := formData.Email
+
+	// NOTE: only a few emails per address (hashed like the login throttle's keys) so the form can't be used to flood
+	// someone's inbox (or the tokens table). over the limit it silently sends nothing, the response stays the same
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+
+	if !app.resetRequests.Allow(hex.EncodeToString(hash[:])) {
+		app.sessionManager.Put(r.Context(), "flash", "If there is an account with that email we've sent it a link to reset the password.")
+		http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+		return
+	}
+
+	app.background(func() {
+		token, err := app.passwordResetModel.Insert(email, passwordResetTTL)
+		if err != nil {
+			if !errors.Is(err, models.ErrNoRecord) {
+				app.errorLog.Printf("creating a password reset token: %s", err)
+			}
+
+			return
+		}
+
+		data := map[string]any{
+			"Link": app.absoluteURL("/user/password/reset?token=" + url.QueryEscape(token)),
+		}
+
+		err = app.sendMail(email, "passwordReset.tmpl", data)
+		if err != nil {
+			app.errorLog.Printf("sending the password reset email: %s", err)
+		}
+	})
+
+	app.sessionManager.Put(r.Context(), "flash", "If there is an account with that email we've sent it a link to reset the password.")
+
+	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+}
+
+type userPasswordResetFormData struct {
+	Token                   string `form:"token"`
+	NewPassword             string `form:"newPassword"`
+	NewPasswordConfirmation string `form:"newPasswordConfirmation"`
+	validator.Validator     `form:"-"`
+}
+
+// NOTE: the page the emailed link points to (/user/password/reset?token=...)
+func (app *application) userPasswordReset(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	_, err := app.passwordResetModel.UserID(token)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.invalidPasswordReset(w, r)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Form = userPasswordResetFormData{Token: token}
+
+	app.render(w, http.StatusOK, "resetPassword.tmpl", data)
+}
+
+func (app *application) userPasswordResetPost(w http.ResponseWriter, r *http.Request) {
+	var formData userPasswordResetFormData
+	err := app.decodePostForm(r, &formData)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	checkNewPassword(&formData.Validator, formData.NewPassword, formData.NewPasswordConfirmation)
+
+	if !formData.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = formData
+		app.render(w, http.StatusUnprocessableEntity, "resetPassword.tmpl", data)
+		return
+	}
+
+	err = app.passwordResetModel.Reset(formData.Token, formData.NewPassword)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.invalidPasswordReset(w, r)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Your password has been reset succesfully! Please login.")
+
+	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+}
+
+// NOTE: sends an unknown, used or expired reset token back to the forgot password form to ask for a new link
+func (app *application) invalidPasswordReset(w http.ResponseWriter, r *http.Request) {
+	app.sessionManager.Put(r.Context(), "flash", "That password reset link is invalid or has expired, please ask for a new one.")
+
+	http.Redirect(w, r, "/user/password/forgot", http.StatusSeeOther)
+}
+
+// NOTE: the page the link in the verification email points to (/user/verify?token=...), works without being logged in
+func (app *application) userVerify(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	id, err := parseVerificationToken(token)
+	if err != nil {
+		app.invalidVerification(w, r)
+		return
+	}
+
+	user, err := app.userModel.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.invalidVerification(w, r)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return
+	}
+
+	if !app.checkVerificationToken(token, user) {
+		app.invalidVerification(w, r)
+		return
+	}
+
+	err = app.userModel.Verify(user.ID)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Your email address has been verified succesfully!")
+
+	if app.isAuthenticated(r) {
+		http.Redirect(w, r, "/snippet/create", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+}
+
+// NOTE: the account page has the button for a new link (and sends whoever isn't logged in to the login page first)
+func (app *application) invalidVerification(w http.ResponseWriter, r *http.Request) {
+	app.sessionManager.Put(r.Context(), "flash", "That verification link is invalid or has expired.")
+
+	http.Redirect(w, r, "/account/view", http.StatusSeeOther)
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"io"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/harshk200/snippetbox/internal/assert"
+	"github.com/harshk200/snippetbox/internal/models"
 )
 
 func TestPing(t *testing.T) {
@@ -38,6 +44,38 @@ func TestSnippetView(t *testing.T) {
 			wantCode: http.StatusOK,
 			wantBody: "test...",
 		},
+		{
+			name:     "Valid slug",
+			urlPath:  "/snippet/view/mockSlugPublic69",
+			wantCode: http.StatusOK,
+			wantBody: "test...",
+		},
+		{
+			name:     "Unlisted by slug",
+			urlPath:  "/snippet/view/mockSlugUnlisted",
+			wantCode: http.StatusOK,
+			wantBody: "unlisted...",
+		},
+		{
+			name:     "Unlisted by ID",
+			urlPath:  "/snippet/view/43",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private by ID",
+			urlPath:  "/snippet/view/44",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private by slug",
+			urlPath:  "/snippet/view/mockSlugPrivate",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent slug",
+			urlPath:  "/snippet/view/mockSlugMissing",
+			wantCode: http.StatusNotFound,
+		},
 		{
 			name:     "Non-existent ID",
 			urlPath:  "/snippet/view/123",
@@ -192,3 +230,1525 @@ func TestSignup(t *testing.T) {
 		})
 	}
 }
+
+func TestUserSnippets(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, header, _ := ts.get(t, "/user/snippets")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		ts.login(t)
+
+		code, _, body := ts.get(t, "/user/snippets")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, `<a href="/snippet/view/69">test...</a>`)
+	})
+}
+
+func TestSnippetEdit(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/snippet/edit/69")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		title        string
+		content      string
+		language     string
+		expires      string
+		expiresIn    string
+		expiresUnit  string
+		expiresAt    string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Valid submission",
+			urlPath:      "/snippet/edit/69",
+			title:        "updated...",
+			content:      "updated-content...",
+			language:     "go",
+			expires:      "in",
+			expiresIn:    "7",
+			expiresUnit:  "days",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/69",
+		},
+		{
+			name:         "Expires in minutes",
+			urlPath:      "/snippet/edit/69",
+			title:        "updated...",
+			content:      "updated-content...",
+			language:     "go",
+			expires:      "in",
+			expiresIn:    "30",
+			expiresUnit:  "minutes",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/69",
+		},
+		{
+			name:         "Expires at",
+			urlPath:      "/snippet/edit/69",
+			title:        "updated...",
+			content:      "updated-content...",
+			language:     "go",
+			expires:      "at",
+			expiresAt:    time.Now().UTC().Add(48 * time.Hour).Format("2006-01-02T15:04"),
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/69",
+		},
+		{
+			name:         "Never expires",
+			urlPath:      "/snippet/edit/69",
+			title:        "updated...",
+			content:      "updated-content...",
+			language:     "go",
+			expires:      "never",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/69",
+		},
+		{
+			name:     "Empty title",
+			urlPath:  "/snippet/edit/69",
+			title:    "",
+			content:  "updated-content...",
+			language: "go",
+			expires:  "never",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Invalid expires",
+			urlPath:  "/snippet/edit/69",
+			title:    "updated...",
+			content:  "updated-content...",
+			language: "go",
+			expires:  "3",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid expires unit",
+			urlPath:     "/snippet/edit/69",
+			title:       "updated...",
+			content:     "updated-content...",
+			language:    "go",
+			expires:     "in",
+			expiresIn:   "2",
+			expiresUnit: "weeks",
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Expires in more than a year",
+			urlPath:     "/snippet/edit/69",
+			title:       "updated...",
+			content:     "updated-content...",
+			language:    "go",
+			expires:     "in",
+			expiresIn:   "366",
+			expiresUnit: "days",
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+		{
+			// NOTE: 213504 days overflows a time.Duration and wraps around to ~25 minutes
+			name:        "Expires in overflowing",
+			urlPath:     "/snippet/edit/69",
+			title:       "updated...",
+			content:     "updated-content...",
+			language:    "go",
+			expires:     "in",
+			expiresIn:   "213504",
+			expiresUnit: "days",
+			wantCode:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Expires at in the past",
+			urlPath:   "/snippet/edit/69",
+			title:     "updated...",
+			content:   "updated-content...",
+			language:  "go",
+			expires:   "at",
+			expiresAt: time.Now().UTC().Add(-time.Hour).Format("2006-01-02T15:04"),
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Unsupported language",
+			urlPath:  "/snippet/edit/69",
+			title:    "updated...",
+			content:  "updated-content...",
+			language: "cobol",
+			expires:  "never",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Not the owner",
+			urlPath:  "/snippet/edit/42",
+			title:    "updated...",
+			content:  "updated-content...",
+			language: "go",
+			expires:  "never",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/edit/123",
+			title:    "updated...",
+			content:  "updated-content...",
+			language: "go",
+			expires:  "never",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("content", tt.content)
+			form.Add("language", tt.language)
+			form.Add("visibility", "public")
+			form.Add("expires", tt.expires)
+			form.Add("expires_in", tt.expiresIn)
+			form.Add("expires_unit", tt.expiresUnit)
+			form.Add("expires_at", tt.expiresAt)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	t.Run("Edit form of other users snippet", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/snippet/edit/42")
+
+		assert.Equal(t, code, http.StatusForbidden)
+	})
+}
+
+func TestSnippetEditKeepsExpiry(t *testing.T) {
+	app := newTestMemoryApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	userID, err := app.userModel.Insert("Bob", "bob@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	// NOTE: expires within the minute, so the pre-filled (to the minute) expiry is in the past already
+	id, err := app.snippetModel.Insert("title", "content", "go", "public", "", false, 30*time.Second, userID, 0)
+	assert.NilError(t, err)
+
+	before, err := app.snippetModel.Get(id)
+	assert.NilError(t, err)
+
+	ts.loginAs(t, "bob@example.com", "pa$$word")
+
+	urlPath := "/snippet/edit/" + strconv.Itoa(id)
+
+	_, _, body := ts.get(t, urlPath)
+
+	expiresAt := before.Expires.UTC().Format("2006-01-02T15:04")
+	assert.StringContains(t, body, `value="`+expiresAt+`"`)
+
+	form := url.Values{}
+	form.Add("title", "updated...")
+	form.Add("content", "content")
+	form.Add("language", "go")
+	form.Add("visibility", "public")
+	form.Add("expires", "at")
+	form.Add("expires_at", expiresAt)
+	form.Add("csrf_token", extractCSRFToken(t, body))
+
+	code, _, _ := ts.postForm(t, urlPath, form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	after, err := app.snippetModel.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, after.Title, "updated...")
+	assert.Equal(t, after.Expires.Sub(before.Expires) >= 0 && after.Expires.Sub(before.Expires) <= time.Second, true)
+}
+
+func TestSnippetDelete(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/snippet/view/69")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Owner",
+			urlPath:      "/snippet/delete/69",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/user/snippets",
+		},
+		{
+			name:     "Not the owner",
+			urlPath:  "/snippet/delete/42",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/delete/123",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestAccountTokens(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	code, _, body := ts.get(t, "/account/tokens")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "<td>ci...</td>")
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	t.Run("Create", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("name", "deploy")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, header, _ := ts.postForm(t, "/account/tokens", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/account/tokens")
+
+		// NOTE: the plaintext token is shown exactly once
+		_, _, body := ts.get(t, "/account/tokens")
+		assert.StringContains(t, body, "MOCKTOKENPLAINTEXT")
+
+		_, _, body = ts.get(t, "/account/tokens")
+		if strings.Contains(body, "MOCKTOKENPLAINTEXT") {
+			t.Errorf("plaintext token was shown more than once")
+		}
+	})
+
+	t.Run("Create with empty name", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("name", "")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/account/tokens", form)
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/account/tokens/delete/1", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		code, _, _ = ts.postForm(t, "/account/tokens/delete/2", form)
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+}
+
+func TestUserLoginThrottle(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	login := func(email, password string) (int, http.Header, string) {
+		form := url.Values{}
+		form.Add("email", email)
+		form.Add("password", password)
+		form.Add("csrf_token", validCSRFToken)
+
+		return ts.postForm(t, "/user/login", form)
+	}
+
+	// NOTE: the test application starts the backoff (1 minute) after 2 failures and locks out for an hour after 4
+	for range 2 {
+		code, _, body := login("test@example.com", "wrong")
+
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "Email or password is incorrect")
+	}
+
+	t.Run("Backoff", func(t *testing.T) {
+		// NOTE: even the right password has to wait
+		code, header, body := login("test@example.com", "password")
+
+		assert.Equal(t, code, http.StatusTooManyRequests)
+		assert.StringContains(t, body, "Too many failed login attempts, please wait")
+
+		// NOTE: the failures are stored with a second precision so up to a second of the wait might be gone already
+		retryAfter, err := strconv.Atoi(header.Get("Retry-After"))
+		assert.NilError(t, err)
+		assert.Equal(t, retryAfter >= 59 && retryAfter <= 60, true)
+	})
+
+	t.Run("Same ip, other email", func(t *testing.T) {
+		code, _, _ := login("unverified@example.com", "password")
+
+		assert.Equal(t, code, http.StatusTooManyRequests)
+	})
+
+	t.Run("Lockout", func(t *testing.T) {
+		keys := loginThrottleKeys("127.0.0.1", "test@example.com")
+
+		for range 2 {
+			_, err := app.loginAttemptModel.Fail(keys[1], time.Hour)
+			assert.NilError(t, err)
+		}
+
+		code, header, body := login("test@example.com", "password")
+
+		assert.Equal(t, code, http.StatusTooManyRequests)
+		assert.StringContains(t, body, "Too many failed login attempts, logging in is locked for 60 minutes.")
+
+		retryAfter, err := strconv.Atoi(header.Get("Retry-After"))
+		assert.NilError(t, err)
+		assert.Equal(t, retryAfter >= 3599 && retryAfter <= 3600, true)
+	})
+
+	t.Run("Success resets the email", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		keys := loginThrottleKeys("127.0.0.1", "test@example.com")
+
+		for _, key := range keys {
+			_, err := app.loginAttemptModel.Fail(key, time.Hour)
+			assert.NilError(t, err)
+		}
+
+		ts.login(t)
+
+		a, err := app.loginAttemptModel.Get(keys[1])
+		assert.NilError(t, err)
+		assert.Equal(t, a.Failures, 0)
+
+		// NOTE: the ip gets the successful attempt back but keeps the earlier failure
+		a, err = app.loginAttemptModel.Get(keys[0])
+		assert.NilError(t, err)
+		assert.Equal(t, a.Failures, 1)
+	})
+}
+
+func TestAccountView(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, header, _ := ts.get(t, "/account/view")
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/login")
+
+	ts.login(t)
+
+	code, _, body := ts.get(t, "/account/view")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "<td>Mock User</td>")
+	assert.StringContains(t, body, "<td>test@example.com</td>")
+	assert.StringContains(t, body, "<td>01 Jan 2022 at 10:00</td>")
+}
+
+func TestAccountPasswordUpdate(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/account/password/update")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	const formTag = `<form action="/account/password/update" method="POST" novalidate>`
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		confirmation    string
+		wantCode        int
+		wantBody        string
+	}{
+		{
+			name:            "Empty current password",
+			currentPassword: "",
+			newPassword:     "new-password",
+			confirmation:    "new-password",
+			wantCode:        http.StatusUnprocessableEntity,
+			wantBody:        formTag,
+		},
+		{
+			name:            "Short new password",
+			currentPassword: "password",
+			newPassword:     "pass",
+			confirmation:    "pass",
+			wantCode:        http.StatusUnprocessableEntity,
+			wantBody:        "password must be at least 8 characters long",
+		},
+		{
+			name:            "Mismatched confirmation",
+			currentPassword: "password",
+			newPassword:     "new-password",
+			confirmation:    "new-passw0rd",
+			wantCode:        http.StatusUnprocessableEntity,
+			wantBody:        "Passwords do not match",
+		},
+		{
+			name:            "Wrong current password",
+			currentPassword: "wrong-password",
+			newPassword:     "new-password",
+			confirmation:    "new-password",
+			wantCode:        http.StatusUnprocessableEntity,
+			wantBody:        "Current password is incorrect",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("currentPassword", tt.currentPassword)
+			form.Add("newPassword", tt.newPassword)
+			form.Add("newPasswordConfirmation", tt.confirmation)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, body := ts.postForm(t, "/account/password/update", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		sessionCookie := func() string {
+			u, _ := url.Parse(ts.URL)
+			for _, c := range ts.Client().Jar.Cookies(u) {
+				if c.Name == "session" {
+					return c.Value
+				}
+			}
+
+			return ""
+		}
+
+		before := sessionCookie()
+
+		form := url.Values{}
+		form.Add("currentPassword", "password")
+		form.Add("newPassword", "new-password")
+		form.Add("newPasswordConfirmation", "new-password")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, header, _ := ts.postForm(t, "/account/password/update", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/account/view")
+
+		// NOTE: the session token is renewed but the user stays logged in
+		if before == "" || sessionCookie() == before {
+			t.Errorf("session token was not renewed")
+		}
+
+		_, _, body := ts.get(t, "/account/view")
+		assert.StringContains(t, body, "Your password has been updated succesfully!")
+	})
+}
+
+func TestEmailVerification(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.loginAs(t, "unverified@example.com", "password")
+
+	code, header, _ := ts.get(t, "/snippet/create")
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/account/view")
+
+	code, _, body := ts.get(t, "/account/view")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Please verify your email address before creating snippets.")
+	assert.StringContains(t, body, `<form action="/account/verification/resend" method="POST">`)
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	t.Run("Resend", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+
+		// NOTE: the test application allows 2 emails per minute
+		for range 2 {
+			code, header, _ := ts.postForm(t, "/account/verification/resend", form)
+			assert.Equal(t, code, http.StatusSeeOther)
+			assert.Equal(t, header.Get("Location"), "/account/view")
+		}
+
+		code, _, body := ts.postForm(t, "/account/verification/resend", form)
+		assert.Equal(t, code, http.StatusTooManyRequests)
+		assert.StringContains(t, body, "Too many verification emails")
+
+		mails := readMails(t, app)
+		assert.Equal(t, len(mails), 2)
+		assert.Equal(t, mails[0].To, "unverified@example.com")
+		assert.Equal(t, mails[0].Subject, "Verify your Snippetbox email address")
+		assert.StringContains(t, mails[0].Body, "https://snippetbox.test/user/verify?token=")
+	})
+
+	tests := []struct {
+		name         string
+		token        string
+		wantLocation string
+	}{
+		{
+			name:         "Invalid token",
+			token:        "WRONG",
+			wantLocation: "/account/view",
+		},
+		{
+			name:         "Unknown user",
+			token:        app.encodeVerificationToken(&models.User{ID: 69, Email: "nobody@example.com"}, time.Now().Add(time.Hour)),
+			wantLocation: "/account/view",
+		},
+		{
+			name:         "Valid token",
+			token:        app.encodeVerificationToken(&models.User{ID: 2, Email: "unverified@example.com"}, time.Now().Add(time.Hour)),
+			wantLocation: "/snippet/create",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, _ := ts.get(t, "/user/verify?token="+tt.token)
+
+			assert.Equal(t, code, http.StatusSeeOther)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	t.Run("Already verified", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t)
+
+		_, _, body := ts.get(t, "/account/view")
+		assert.StringContains(t, body, "<td>Yes</td>")
+
+		form := url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, _, _ := ts.postForm(t, "/account/verification/resend", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		_, _, body = ts.get(t, "/account/view")
+		assert.StringContains(t, body, "Your email address is already verified.")
+	})
+}
+
+func TestPasswordForgot(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+	assert.StringContains(t, body, `<a href="/user/password/forgot">Forgot your password?</a>`)
+
+	_, _, body = ts.get(t, "/user/password/forgot")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	forgot := func(email string) (int, http.Header, string) {
+		form := url.Values{}
+		form.Add("email", email)
+		form.Add("csrf_token", validCSRFToken)
+
+		return ts.postForm(t, "/user/password/forgot", form)
+	}
+
+	t.Run("Invalid email", func(t *testing.T) {
+		code, _, body := forgot("bob@example.")
+
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "This field must be a valid email address")
+	})
+
+	// NOTE: the same response whether or not there is an account, only the email tells them apart
+	t.Run("Unknown email", func(t *testing.T) {
+		code, header, _ := forgot("nobody@example.com")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+		assert.Equal(t, len(readMails(t, app)), 0)
+	})
+
+	t.Run("Known email", func(t *testing.T) {
+		code, header, _ := forgot("test@example.com")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+
+		_, _, body := ts.get(t, "/user/login")
+		assert.StringContains(t, body, "If there is an account with that email")
+
+		mails := readMails(t, app)
+		assert.Equal(t, len(mails), 1)
+		assert.Equal(t, mails[0].To, "test@example.com")
+		assert.Equal(t, mails[0].Subject, "Reset your Snippetbox password")
+		assert.StringContains(t, mails[0].Body, "https://snippetbox.test/user/password/reset?token=MOCKRESETTOKEN\r\n")
+	})
+
+	// NOTE: the limit is 2 per minute in the tests, the one above was the first
+	t.Run("Too many", func(t *testing.T) {
+		for range 3 {
+			code, header, _ := forgot("test@example.com")
+
+			assert.Equal(t, code, http.StatusSeeOther)
+			assert.Equal(t, header.Get("Location"), "/user/login")
+
+			_, _, body := ts.get(t, "/user/login")
+			assert.StringContains(t, body, "If there is an account with that email")
+		}
+
+		assert.Equal(t, len(readMails(t, app)), 2)
+	})
+}
+
+func TestPasswordReset(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, header, _ := ts.get(t, "/user/password/reset?token=WRONG")
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/password/forgot")
+
+	_, _, body := ts.get(t, "/user/password/forgot")
+	assert.StringContains(t, body, "That password reset link is invalid or has expired")
+
+	code, _, body = ts.get(t, "/user/password/reset?token=MOCKRESETTOKEN")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `<input type="hidden" name="token" value="MOCKRESETTOKEN">`)
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		token        string
+		newPassword  string
+		confirmation string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Short password",
+			token:        "MOCKRESETTOKEN",
+			newPassword:  "pass",
+			confirmation: "pass",
+			wantCode:     http.StatusUnprocessableEntity,
+			wantBody:     "password must be at least 8 characters long",
+		},
+		{
+			name:         "Mismatched confirmation",
+			token:        "MOCKRESETTOKEN",
+			newPassword:  "new-password",
+			confirmation: "new-passw0rd",
+			wantCode:     http.StatusUnprocessableEntity,
+			wantBody:     "Passwords do not match",
+		},
+		{
+			name:         "Invalid token",
+			token:        "WRONG",
+			newPassword:  "new-password",
+			confirmation: "new-password",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/user/password/forgot",
+		},
+		{
+			name:         "Valid",
+			token:        "MOCKRESETTOKEN",
+			newPassword:  "new-password",
+			confirmation: "new-password",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/user/login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("token", tt.token)
+			form.Add("newPassword", tt.newPassword)
+			form.Add("newPasswordConfirmation", tt.confirmation)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, body := ts.postForm(t, "/user/password/reset", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	app := newTestMemoryApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, err := app.userModel.Insert("Bob", "bob@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	_, _, body := ts.get(t, "/user/password/forgot")
+
+	form := url.Values{}
+	form.Add("email", "BOB@example.com")
+	form.Add("csrf_token", extractCSRFToken(t, body))
+
+	code, _, _ := ts.postForm(t, "/user/password/forgot", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	mails := readMails(t, app)
+	assert.Equal(t, len(mails), 1)
+
+	link := extractMailLink(t, mails[0].Body)
+
+	code, _, body = ts.get(t, link)
+	assert.Equal(t, code, http.StatusOK)
+
+	u, err := url.Parse(link)
+	assert.NilError(t, err)
+
+	reset := url.Values{}
+	reset.Add("token", u.Query().Get("token"))
+	reset.Add("newPassword", "new-pa$$word")
+	reset.Add("newPasswordConfirmation", "new-pa$$word")
+	reset.Add("csrf_token", extractCSRFToken(t, body))
+
+	code, header, _ := ts.postForm(t, "/user/password/reset", reset)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/login")
+
+	_, err = app.userModel.Authenticate("bob@example.com", "pa$$word")
+	assert.Equal(t, err, models.ErrInvalidCredentials)
+
+	ts.loginAs(t, "bob@example.com", "new-pa$$word")
+
+	// NOTE: the link only works once
+	code, header, _ = ts.postForm(t, "/user/password/reset", reset)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/password/forgot")
+}
+
+func TestSnippetSearch(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Empty query",
+			urlPath:  "/search",
+			wantCode: http.StatusOK,
+			wantBody: `<form action="/search" method="GET" novalidate>`,
+		},
+		{
+			name:     "Matching query",
+			urlPath:  "/search?q=test",
+			wantCode: http.StatusOK,
+			wantBody: `<mark>test</mark>...`,
+		},
+		{
+			name:     "No results",
+			urlPath:  "/search?q=foo",
+			wantCode: http.StatusOK,
+			wantBody: "No snippets matched your search.",
+		},
+		{
+			name:     "Too long query",
+			urlPath:  "/search?q=" + strings.Repeat("a", 101),
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be more than 100 characters long",
+		},
+		{
+			name:     "Invalid page",
+			urlPath:  "/search?q=test&page=foo",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Overflowing page",
+			urlPath:  "/search?q=test&page=" + strconv.Itoa(math.MaxInt),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Last allowed page",
+			urlPath:  "/search?q=test&page=" + strconv.Itoa(math.MaxInt/searchResultsPerPage),
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetArchive(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "First page",
+			urlPath:  "/snippets",
+			wantCode: http.StatusOK,
+			wantBody: `<a href="/snippet/view/69">test...</a>`,
+		},
+		{
+			name:     "Valid cursor",
+			urlPath:  "/snippets?before=" + app.encodeCursor(1),
+			wantCode: http.StatusOK,
+			wantBody: `<a href="?after=` + app.encodeCursor(69) + `">Next</a>`,
+		},
+		{
+			name:     "Tampered cursor",
+			urlPath:  "/snippets?after=foo",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetUnlock(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	const unlockForm = `<form action="/snippet/view/45" method="POST" novalidate>`
+
+	code, _, body := ts.get(t, "/snippet/view/45")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, unlockForm)
+	if strings.Contains(body, "protected-content...") {
+		t.Fatal("protected content shown before unlocking")
+	}
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	unlock := func(password string) (int, string) {
+		form := url.Values{}
+		form.Add("password", password)
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, body := ts.postForm(t, "/snippet/view/45", form)
+		return code, body
+	}
+
+	t.Run("Wrong password", func(t *testing.T) {
+		code, body := unlock("wrong")
+
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "Incorrect password")
+	})
+
+	t.Run("Correct password", func(t *testing.T) {
+		code, _ := unlock("secret")
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		// NOTE: the session now remembers the snippet as unlocked
+		code, _, body := ts.get(t, "/snippet/view/45")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "protected-content...")
+	})
+
+	t.Run("Rate limited", func(t *testing.T) {
+		// NOTE: the test application allows 3 failures per minute
+		unlock("wrong")
+		unlock("wrong")
+		unlock("wrong")
+
+		code, body := unlock("secret")
+		assert.Equal(t, code, http.StatusTooManyRequests)
+		assert.StringContains(t, body, "Too many failed attempts")
+	})
+}
+
+func TestSnippetCreate(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	code, _, body := ts.get(t, "/snippet/create")
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `<form action="/snippet/create" method="POST">`)
+	assert.StringContains(t, body, `<input type="password" name="password" placeholder="">`)
+}
+
+func TestSnippetBurnAfterReading(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Viewed by someone else", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/46")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "burn-content...")
+		assert.StringContains(t, body, "This snippet has been deleted now.")
+	})
+
+	t.Run("Create flash", func(t *testing.T) {
+		ts.login(t)
+
+		_, _, body := ts.get(t, "/snippet/create")
+
+		form := url.Values{}
+		form.Add("title", "burn...")
+		form.Add("content", "burn-content...")
+		form.Add("language", "plaintext")
+		form.Add("visibility", "public")
+		form.Add("burn_after_reading", "true")
+		form.Add("expires", "in")
+		form.Add("expires_in", "1")
+		form.Add("expires_unit", "days")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, header, _ := ts.postForm(t, "/snippet/create", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/snippet/view/420")
+
+		_, _, body = ts.get(t, "/")
+		assert.StringContains(t, body, "The link is one-time only")
+	})
+}
+
+func TestSnippetCreateThenView(t *testing.T) {
+	app := newTestMemoryApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/signup")
+
+	form := url.Values{}
+	form.Add("name", "Bob")
+	form.Add("email", "bob@example.com")
+	form.Add("password", "pa$$word")
+	form.Add("csrf_token", extractCSRFToken(t, body))
+
+	code, _, _ := ts.postForm(t, "/user/signup", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	t.Run("Duplicate email", func(t *testing.T) {
+		form.Set("email", "BOB@example.com")
+
+		code, _, body := ts.postForm(t, "/user/signup", form)
+
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "Email address is already in use")
+	})
+
+	ts.loginAs(t, "bob@example.com", "pa$$word")
+
+	// NOTE: the snippets can only be created once the email is verified
+	code, header, _ := ts.get(t, "/snippet/create")
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/account/view")
+
+	mails := readMails(t, app)
+	assert.Equal(t, len(mails), 1)
+	assert.Equal(t, mails[0].To, "bob@example.com")
+
+	code, header, _ = ts.get(t, extractMailLink(t, mails[0].Body))
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/snippet/create")
+
+	create := func(t *testing.T, title, expires string) string {
+		_, _, body := ts.get(t, "/snippet/create")
+
+		form := url.Values{}
+		form.Add("title", title)
+		form.Add("content", "fmt.Println(\"hello\")")
+		form.Add("language", "go")
+		form.Add("visibility", "public")
+		form.Add("expires", expires)
+		form.Add("expires_in", "1")
+		form.Add("expires_unit", "days")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, header, _ := ts.postForm(t, "/snippet/create", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		return header.Get("Location")
+	}
+
+	t.Run("View", func(t *testing.T) {
+		location := create(t, "Hello from memory", "in")
+		assert.Equal(t, location, "/snippet/view/1")
+
+		code, _, body := ts.get(t, location)
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "Hello from memory")
+
+		_, _, body = ts.get(t, "/user/snippets")
+		assert.StringContains(t, body, "Hello from memory")
+	})
+
+	t.Run("Never expires", func(t *testing.T) {
+		location := create(t, "Forever", "never")
+
+		_, _, body := ts.get(t, location)
+		assert.StringContains(t, body, "Never")
+
+		_, _, body = ts.get(t, "/")
+		assert.StringContains(t, body, "Forever")
+	})
+}
+
+func TestSnippetHistory(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantBody     string
+		wantLocation string
+	}{
+		{
+			name:     "History",
+			urlPath:  "/snippet/view/69/history",
+			wantCode: http.StatusOK,
+			wantBody: "<td>Mock User</td>",
+		},
+		{
+			name:     "Last change",
+			urlPath:  "/snippet/view/69/diff",
+			wantCode: http.StatusOK,
+			wantBody: `<span class="diff-delete">-first-content...</span>`,
+		},
+		{
+			name:     "Any two revisions",
+			urlPath:  "/snippet/view/69/diff?from=2&to=1",
+			wantCode: http.StatusOK,
+			wantBody: `<span class="diff-insert">&#43;first-content...</span>`, // NOTE: html/template escapes the +
+		},
+		{
+			name:     "Same revision",
+			urlPath:  "/snippet/view/42/diff",
+			wantCode: http.StatusOK,
+			wantBody: "The content is the same in both revisions.",
+		},
+		{
+			name:     "Revision of another snippet",
+			urlPath:  "/snippet/view/69/diff?from=142",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Unlisted by slug",
+			urlPath:  "/snippet/view/mockSlugUnlisted/history",
+			wantCode: http.StatusOK,
+			wantBody: "unlisted...",
+		},
+		{
+			name:     "Unlisted by ID",
+			urlPath:  "/snippet/view/43/history",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private",
+			urlPath:  "/snippet/view/44/diff",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:         "Locked",
+			urlPath:      "/snippet/view/45/history",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/45",
+		},
+		{
+			name:     "Burn after reading",
+			urlPath:  "/snippet/view/46/history",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+
+	t.Run("Restore button", func(t *testing.T) {
+		_, _, body := ts.get(t, "/snippet/view/69/history")
+		assert.Equal(t, strings.Contains(body, "Restore"), false)
+
+		ts.login(t)
+
+		_, _, body = ts.get(t, "/snippet/view/69/history")
+		assert.StringContains(t, body, `<input type="hidden" name="revision" value="1">`)
+	})
+}
+
+func TestSnippetRestore(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/snippet/view/69")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		revision     string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Owner",
+			urlPath:      "/snippet/restore/69",
+			revision:     "1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/69",
+		},
+		{
+			name:     "Revision of another snippet",
+			urlPath:  "/snippet/restore/69",
+			revision: "142",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Not the owner",
+			urlPath:  "/snippet/restore/42",
+			revision: "142",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("revision", tt.revision)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestSnippetFork(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("View", func(t *testing.T) {
+		_, _, body := ts.get(t, "/snippet/view/69")
+		assert.StringContains(t, body, `<a href="/snippet/view/69/forks">1 fork</a>`)
+		assert.Equal(t, strings.Contains(body, "Fork</a>"), false)
+
+		_, _, body = ts.get(t, "/snippet/view/47")
+		assert.StringContains(t, body, `forked from <a href="/snippet/view/69">#69</a>`)
+
+		_, _, body = ts.get(t, "/snippet/view/69/forks")
+		assert.StringContains(t, body, `<a href="/snippet/view/47">fork...</a>`)
+	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		ref      string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Public",
+			ref:      "42",
+			wantCode: http.StatusOK,
+			wantBody: `<input type="hidden" name="fork" value="42">`,
+		},
+		{
+			name:     "Unlisted by slug",
+			ref:      "mockSlugUnlisted",
+			wantCode: http.StatusOK,
+			wantBody: "unlisted-content...",
+		},
+		{
+			name:     "Private",
+			ref:      "44",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Locked",
+			ref:      "45",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Burn after reading",
+			ref:      "46",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, "/snippet/create?fork="+tt.ref)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+
+	_, _, body := ts.get(t, "/snippet/view/42")
+	assert.StringContains(t, body, `<a href="/snippet/create?fork=42">Fork</a>`)
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	post := func(t *testing.T, ref string) (int, http.Header, string) {
+		form := url.Values{}
+		form.Add("title", "fork...")
+		form.Add("content", "fork-content...")
+		form.Add("language", "go")
+		form.Add("visibility", "public")
+		form.Add("expires", "never")
+		form.Add("fork", ref)
+		form.Add("csrf_token", validCSRFToken)
+
+		return ts.postForm(t, "/snippet/create", form)
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		code, header, _ := post(t, "42")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/snippet/view/420")
+	})
+
+	t.Run("Create from a private snippet", func(t *testing.T) {
+		code, _, body := post(t, "44")
+
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "The snippet you are forking doesn&#39;t exist anymore")
+	})
+}
+
+func TestSnippetRaw(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name             string
+		urlPath          string
+		wantCode         int
+		wantBody         string
+		wantCacheControl string
+		wantDisposition  string
+	}{
+		{
+			name:             "Raw",
+			urlPath:          "/snippet/raw/69",
+			wantCode:         http.StatusOK,
+			wantBody:         "test-content...",
+			wantCacheControl: "public, max-age=300",
+		},
+		{
+			name:             "Download",
+			urlPath:          "/snippet/download/69",
+			wantCode:         http.StatusOK,
+			wantBody:         "test-content...",
+			wantCacheControl: "public, max-age=300",
+			wantDisposition:  "attachment; filename=test.go",
+		},
+		{
+			name:             "Unlisted by slug",
+			urlPath:          "/snippet/raw/mockSlugUnlisted",
+			wantCode:         http.StatusOK,
+			wantBody:         "unlisted-content...",
+			wantCacheControl: "private, no-cache",
+		},
+		{
+			name:     "Unlisted by ID",
+			urlPath:  "/snippet/raw/43",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private",
+			urlPath:  "/snippet/download/44",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Locked",
+			urlPath:  "/snippet/raw/45",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:             "Burn after reading",
+			urlPath:          "/snippet/raw/46",
+			wantCode:         http.StatusOK,
+			wantBody:         "burn-content...",
+			wantCacheControl: "no-store",
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/raw/123",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Content-Disposition"), tt.wantDisposition)
+
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, header.Get("Content-Type"), "text/plain; charset=utf-8")
+				assert.Equal(t, header.Get("Cache-Control"), tt.wantCacheControl)
+				assert.Equal(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("Not modified", func(t *testing.T) {
+		_, header, _ := ts.get(t, "/snippet/raw/69")
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/snippet/raw/69", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("If-None-Match", header.Get("ETag"))
+
+		rs, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs.Body.Close()
+
+		assert.Equal(t, rs.StatusCode, http.StatusNotModified)
+	})
+
+	t.Run("Burn after reading with a range", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/snippet/raw/46", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Range", "bytes=0-3")
+
+		rs, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rs.Body.Close()
+
+		body, err := io.ReadAll(rs.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: it's gone after this one read, so all of it
+		assert.Equal(t, rs.StatusCode, http.StatusOK)
+		assert.Equal(t, string(body), "burn-content...")
+		assert.Equal(t, rs.Header.Get("ETag"), "")
+	})
+}
+
+func TestSnippetFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		language string
+		want     string
+	}{
+		{name: "Punctuation", title: "Hello, World!", language: "go", want: "hello-world.go"},
+		{name: "Unsupported language", title: "notes", language: "cobol", want: "notes.txt"},
+		{name: "No usable characters", title: "こんにちは", language: "python", want: "snippet-7.py"},
+		{name: "Long title", title: strings.Repeat("ab ", 30), language: "plaintext", want: strings.Repeat("ab-", 16) + "ab.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := &models.Snippet{ID: 7, Title: tt.title, Language: tt.language}
+
+			assert.Equal(t, snippetFilename(snippet), tt.want)
+		})
+	}
+}
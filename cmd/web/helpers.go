@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"net/http"
 	"runtime/debug"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/form/v4"
+	"github.com/harshk200/snippetbox/internal/models"
+	"github.com/julienschmidt/httprouter"
 )
 
 func (app *application) serverError(w http.ResponseWriter, err error) {
@@ -84,3 +88,220 @@ func (app *application) isAuthenticated(r *http.Request) bool {
 
 	return isAuthenticated
 }
+
+// returns the ID of the currently logged in user (0 if the request isn't authenticated)
+func (app *application) authenticatedUserID(r *http.Request) int {
+	id, ok := r.Context().Value(authenticatedUserIDContextKey).(int)
+	if !ok {
+		return 0
+	}
+
+	return id
+}
+
+func (app *application) isTokenAuthenticated(r *http.Request) bool {
+	isTokenAuthenticated, ok := r.Context().Value(isTokenAuthenticatedContextKey).(bool)
+	if !ok {
+		return false
+	}
+
+	return isTokenAuthenticated
+}
+
+// NOTE: fetches the snippet from the :id route param and checks that the logged in user owns it.
+// if ok is false the error response has already been written and the handler should just return
+func (app *application) ownedSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	params := httprouter.ParamsFromContext(r.Context())
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w)
+		return nil, false
+	}
+
+	snippet, err := app.snippetModel.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return nil, false
+	}
+
+	if snippet.UserID != app.authenticatedUserID(r) {
+		app.clientError(w, http.StatusForbidden)
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// reports whether the current user may see the snippet. the owner can always see it, everyone else only
+// if it's public or if it's unlisted and was looked up by it's slug
+func (app *application) canView(r *http.Request, snippet *models.Snippet, bySlug bool) bool {
+	if app.isAuthenticated(r) && snippet.UserID == app.authenticatedUserID(r) {
+		return true
+	}
+
+	switch snippet.Visibility {
+	case models.VisibilityPublic:
+		return true
+	case models.VisibilityUnlisted:
+		return bySlug
+	default:
+		return false
+	}
+}
+
+// NOTE: looks the snippet up by it's ref (the numeric ID or the slug) and checks that the current user may see it.
+// a snippet they can't see is ErrNoRecord too, so we don't leak that it exists
+func (app *application) snippetByRef(r *http.Request, ref string) (*models.Snippet, error) {
+	var snippet *models.Snippet
+	bySlug := false
+
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		bySlug = true
+		snippet, err = app.snippetModel.GetBySlug(ref)
+	} else {
+		snippet, err = app.snippetModel.Get(id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !app.canView(r, snippet, bySlug) {
+		return nil, models.ErrNoRecord
+	}
+
+	return snippet, nil
+}
+
+// NOTE: fetches the snippet from the :id route param (numeric ID or slug) and checks that the current user may see it.
+// if ok is false the error response has already been written and the handler should just return
+func (app *application) viewableSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	snippet, err := app.snippetByRef(r, httprouter.ParamsFromContext(r.Context()).ByName("id"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// NOTE: same as snippetByRef but only for the snippets whose content the current user may copy into a fork i.e. the
+// password protected ones have to be unlocked and the burn after reading ones can only be forked by their owner
+// (someone else forking it would read it without burning it)
+func (app *application) forkableSnippet(r *http.Request, ref string) (*models.Snippet, error) {
+	snippet, err := app.snippetByRef(r, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	isOwner := app.isAuthenticated(r) && snippet.UserID == app.authenticatedUserID(r)
+
+	if (snippet.BurnAfterReading && !isOwner) || !app.isUnlocked(r, snippet) {
+		return nil, models.ErrNoRecord
+	}
+
+	return snippet, nil
+}
+
+// returns the snippet this one was forked from, nil if it isn't a fork or the current user can't see the original
+func (app *application) forkedFrom(r *http.Request, snippet *models.Snippet) (*models.Snippet, error) {
+	if snippet.ForkedFrom == 0 {
+		return nil, nil
+	}
+
+	source, err := app.snippetModel.Get(snippet.ForkedFrom)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil, nil // NOTE: expired (a deleted one unlinks it's forks)
+		}
+
+		return nil, err
+	}
+
+	// NOTE: the link uses the slug for unlisted snippets so only the ones that can be seen without it are shown
+	if !app.canView(r, source, false) {
+		return nil, nil
+	}
+
+	return source, nil
+}
+
+// returns the forks of the snippet the current user can see
+func (app *application) visibleForks(r *http.Request, snippet *models.Snippet) ([]*models.Snippet, error) {
+	forks, err := app.snippetModel.Forks(snippet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(forks, func(fork *models.Snippet) bool {
+		return !app.canView(r, fork, false)
+	}), nil
+}
+
+// NOTE: same as viewableSnippet but for the pages showing the snippet's revisions, which hold it's content too. a
+// password protected snippet that isn't unlocked yet redirects to it's unlock form and a burn after reading snippet
+// has no history for anyone but it's owner (showing it wouldn't burn the snippet)
+func (app *application) historySnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	isOwner := app.isAuthenticated(r) && snippet.UserID == app.authenticatedUserID(r)
+
+	if snippet.BurnAfterReading && !isOwner {
+		app.notFound(w)
+		return nil, false
+	}
+
+	if !app.isUnlocked(r, snippet) {
+		http.Redirect(w, r, "/snippet/view/"+httprouter.ParamsFromContext(r.Context()).ByName("id"), http.StatusSeeOther)
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// session key that marks a password protected snippet as unlocked
+func unlockedSnippetKey(id int) string {
+	return fmt.Sprintf("unlockedSnippet:%d", id)
+}
+
+// reports whether the content of the snippet may be shown i.e. it isn't password protected, the current user owns it
+// or it was unlocked in this session
+func (app *application) isUnlocked(r *http.Request, snippet *models.Snippet) bool {
+	if !snippet.IsProtected() {
+		return true
+	}
+
+	if app.isAuthenticated(r) && snippet.UserID == app.authenticatedUserID(r) {
+		return true
+	}
+
+	return app.sessionManager.GetBool(r.Context(), unlockedSnippetKey(snippet.ID))
+}
+
+// NOTE: burn after reading snippets get deleted the first time someone other than the owner views them. the returned
+// snippet is the one read inside the burning transaction, ErrNoRecord means someone else burned it first
+func (app *application) burnIfNeeded(r *http.Request, snippet *models.Snippet) (*models.Snippet, error) {
+	if !snippet.BurnAfterReading {
+		return snippet, nil
+	}
+
+	if app.isAuthenticated(r) && snippet.UserID == app.authenticatedUserID(r) {
+		return snippet, nil
+	}
+
+	return app.snippetModel.Burn(snippet.ID)
+}
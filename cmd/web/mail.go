@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/harshk200/snippetbox/ui"
+)
+
+// renders the "subject" and "body" of the ui/mail template with data and sends the email to the recipient.
+// NOTE: text/template as the emails are plain text (html/template would escape the links)
+func (app *application) sendMail(recipient, name string, data any) error {
+	ts, err := template.ParseFS(ui.Files, "mail/"+name)
+	if err != nil {
+		return err
+	}
+
+	var subject, body strings.Builder
+
+	err = ts.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return err
+	}
+
+	err = ts.ExecuteTemplate(&body, "body", data)
+	if err != nil {
+		return err
+	}
+
+	return app.mailer.Send(recipient, strings.TrimSpace(subject.String()), strings.TrimSpace(body.String())+"\n")
+}
+
+// returns the absolute url of path (which must start with a /) for the links in the emails
+func (app *application) absoluteURL(path string) string {
+	return strings.TrimSuffix(app.baseURL, "/") + path
+}
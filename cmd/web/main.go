@@ -1,63 +1,88 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"crypto/tls"
-	"database/sql"
+	"errors"
 	"flag"
 	"html/template"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/alexedwards/scs/mysqlstore"
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-playground/form/v4"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/harshk200/snippetbox/internal/mailer"
 	"github.com/harshk200/snippetbox/internal/models"
+	"github.com/harshk200/snippetbox/internal/models/memory"
 )
 
 type application struct {
-	errorLog       *log.Logger
-	infoLog        *log.Logger
-	snippetModel   models.SnippetModelInterface
-	userModel      models.UserModelInterface
-	templateCache  map[string]*template.Template
-	formDecoder    *form.Decoder
-	sessionManager *scs.SessionManager
+	errorLog            *log.Logger
+	infoLog             *log.Logger
+	snippetModel        models.SnippetModelInterface
+	userModel           models.UserModelInterface
+	apiTokenModel       models.APITokenModelInterface
+	sessionModel        models.SessionModelInterface
+	passwordResetModel  models.PasswordResetModelInterface
+	loginAttemptModel   models.LoginAttemptModelInterface
+	templateCache       map[string]*template.Template
+	formDecoder         *form.Decoder
+	sessionManager      *scs.SessionManager
+	secretKey           []byte // NOTE: used for signing values we hand out to clients (pagination cursors and verification links)
+	unlockAttempts      *failedAttempts
+	verificationResends *failedAttempts // NOTE: the verification emails each user asked for (to limit them)
+	resetRequests       *failedAttempts // NOTE: the password reset emails asked for per email (to limit them)
+	loginThrottle       *loginThrottle
+	ipLimiter           *rateLimiter // NOTE: the per ip limit in front of the session (see routes.go)
+	dynamicLimiter      *rateLimiter // NOTE: the request rate limits of the route groups (see routes.go)
+	protectedLimiter    *rateLimiter
+	staticLimiter       *rateLimiter
+	mailer              mailer.Mailer
+	baseURL             string         // NOTE: the public url of the app, for the links in the emails
+	wg                  sync.WaitGroup // NOTE: tracks the background goroutines (see app.background)
 }
 
-func openDB(dns string) (*sql.DB, error) {
-	// NOTE: this db here is not a connection it's a connection pool (i.e. no connections are made yet but when they do this will hold them)
-	// also this is concurrent safe
-	db, err := sql.Open("mysql", dns)
-	if err != nil {
-		return nil, err
+func main() {
+	cfg, err := loadConfig(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
-
-	// NOTE: just testing if the connection to db is good or not?
-	err = db.Ping()
 	if err != nil {
-		return nil, err
+		log.Fatal(err)
 	}
 
-	return db, nil
-}
+	if cfg.printConfig {
+		err = cfg.print(os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
 
-func main() {
-	addr := flag.String("addr", ":3000", "HTTP network address")
-	dns := flag.String("dns", "web:password@/snippetbox?parseTime=true&interpolateParams=true", "DNS or connection string for MySQl connection")
+		return
+	}
 
-	flag.Parse()
+	if len(cfg.args) > 0 {
+		err = runCommand(cfg, cfg.args, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
 
-	infoLog := log.New(os.Stdout, "\u001b[34mINFO\u001b[0m\t", log.Ldate|log.Ltime)
-	errorLog := log.New(os.Stderr, "\u001b[31mERROR\u001b[0m\t", log.Ldate|log.Ltime|log.Lshortfile)
+		return
+	}
 
-	db, err := openDB(*dns)
+	// NOTE: failing fast on a bad config instead of when it's first used
+	err = cfg.validate()
 	if err != nil {
-		errorLog.Fatal(err)
+		log.Fatalf("invalid config:\n%s", err)
 	}
-	defer db.Close()
+
+	infoLog := log.New(os.Stdout, "\u001b[34mINFO\u001b[0m\t", log.Ldate|log.Ltime)
+	errorLog := log.New(os.Stderr, "\u001b[31mERROR\u001b[0m\t", log.Ldate|log.Ltime|log.Lshortfile)
 
 	templateCache, err := newTemplateCache()
 	if err != nil {
@@ -66,36 +91,127 @@ func main() {
 
 	formDecoder := form.NewDecoder()
 
+	secretKey := []byte(cfg.Secret)
+	if len(secretKey) == 0 {
+		// NOTE: only with the in-memory storage (validate requires it otherwise). with a random key the cursors and
+		// the verification links handed out stop working after a restart
+		secretKey = make([]byte, 32)
+		_, err = rand.Read(secretKey)
+		if err != nil {
+			errorLog.Fatal(err)
+		}
+
+		infoLog.Println("No -secret provided, using a randomly generated one (the cursors and verification links stop working after a restart)")
+	}
+
 	sessionManager := scs.New()
-	sessionManager.Store = mysqlstore.New(db)
-	sessionManager.Lifetime = 12 * time.Hour
+	sessionManager.Lifetime = cfg.SessionLifetime.Duration
 	sessionManager.Cookie.Secure = true
 
 	app := &application{
-		infoLog:        infoLog,
-		errorLog:       errorLog,
-		snippetModel:   &models.SnippetModel{DB: db}, // NOTE: creating the new snippetModel Instance here
-		userModel:      &models.UserModel{DB: db},
-		templateCache:  templateCache,
-		formDecoder:    formDecoder,
-		sessionManager: sessionManager,
+		infoLog:             infoLog,
+		errorLog:            errorLog,
+		templateCache:       templateCache,
+		formDecoder:         formDecoder,
+		sessionManager:      sessionManager,
+		secretKey:           secretKey,
+		unlockAttempts:      newFailedAttempts(5, 15*time.Minute),
+		verificationResends: newFailedAttempts(3, time.Hour),
+		resetRequests:       newFailedAttempts(3, time.Hour),
+		ipLimiter:           newRateLimiter(cfg.RateLimitIP.Rate, cfg.RateLimitIP.Burst),
+		dynamicLimiter:      newRateLimiter(cfg.RateLimitDynamic.Rate, cfg.RateLimitDynamic.Burst),
+		protectedLimiter:    newRateLimiter(cfg.RateLimitProtected.Rate, cfg.RateLimitProtected.Burst),
+		staticLimiter:       newRateLimiter(cfg.RateLimitStatic.Rate, cfg.RateLimitStatic.Burst),
+		baseURL:             cfg.BaseURL,
+	}
+
+	if cfg.SMTPHost != "" {
+		app.mailer = &mailer.SMTP{Host: cfg.SMTPHost, Port: cfg.SMTPPort, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword, Sender: cfg.MailSender}
+	} else {
+		infoLog.Printf("No -smtp-host provided, the emails are written to %s", cfg.MailDir)
+
+		app.mailer = &mailer.File{Dir: cfg.MailDir, Sender: cfg.MailSender}
+	}
+
+	var db *database
+
+	if cfg.Storage == storageMemory {
+		// NOTE: the sessions stay in scs' default memstore
+		infoLog.Println("Using the in-memory storage, all the data is lost when the server stops")
+
+		users := &memory.UserModel{}
+
+		app.snippetModel = &memory.SnippetModel{Users: users}
+		app.userModel = users
+		app.apiTokenModel = &memory.APITokenModel{}
+		app.sessionModel = &memory.SessionModel{}
+		app.passwordResetModel = &memory.PasswordResetModel{Users: users}
+		app.loginAttemptModel = &memory.LoginAttemptModel{}
+	} else {
+		db, err = openDB(cfg.DNS, false)
+		if err != nil {
+			errorLog.Fatal(err)
+		}
+
+		sessionManager.Store = db.sessionStore()
+
+		app.snippetModel = &models.SnippetModel{DB: db.DB, Dialect: db.dialect} // NOTE: creating the new snippetModel Instance here
+		app.userModel = &models.UserModel{DB: db.DB, Dialect: db.dialect}
+		app.apiTokenModel = &models.APITokenModel{DB: db.DB, Dialect: db.dialect}
+		app.sessionModel = &models.SessionModel{DB: db.DB, Dialect: db.dialect}
+		app.passwordResetModel = &models.PasswordResetModel{DB: db.DB, Dialect: db.dialect}
+		app.loginAttemptModel = &models.LoginAttemptModel{DB: db.DB, Dialect: db.dialect}
 	}
 
+	// NOTE: the backoff starts after 3 failures (1s, 2s, 4s, ...) and the 10th locks the key out for 15 minutes
+	app.loginThrottle = newLoginThrottle(app.loginAttemptModel, 3, time.Second, 10, 15*time.Minute)
+
 	tlsConfig := &tls.Config{
 		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
 	}
 
 	srv := &http.Server{
-		Addr:         *addr,
+		Addr:         cfg.Addr,
 		ErrorLog:     errorLog,
 		Handler:      app.routes(),
 		TLSConfig:    tlsConfig,
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  cfg.IdleTimeout.Duration,
+		ReadTimeout:  cfg.ReadTimeout.Duration,
+		WriteTimeout: cfg.WriteTimeout.Duration,
+	}
+
+	// NOTE: cancelled on SIGINT/SIGTERM, which stops the server and the background goroutines. after that a second
+	// signal kills the process right away (stop restores the default behaviour)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.SweepInterval.Duration > 0 {
+		app.background(func() {
+			app.sweepExpired(ctx, cfg.SweepInterval.Duration, cfg.ExpiredGrace.Duration)
+		})
+	}
+
+	app.background(func() {
+		app.evictBuckets(ctx, bucketEvictInterval)
+	})
+
+	app.background(func() {
+		app.evictAttempts(ctx, attemptsEvictInterval)
+	})
+
+	err = app.serve(ctx, srv, cfg.TLSCert, cfg.TLSKey, cfg.ShutdownTimeout.Duration)
+	if err != nil {
+		errorLog.Print(err)
+	}
+
+	if db != nil {
+		infoLog.Println("Closing the database connection pool")
+		db.Close()
+	}
+
+	if err != nil {
+		os.Exit(1)
 	}
 
-	infoLog.Printf("Starting server on port %s\n", *addr)
-	err = srv.ListenAndServeTLS("./tls/cert.pem", "./tls/key.pem")
-	errorLog.Fatal(err) // NOTE: ListenAndServe always return a not-nil error so no need to put a if err != nil check here
+	infoLog.Println("Server stopped")
 }
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/harshk200/snippetbox/internal/models"
 	"github.com/justinas/nosurf"
 )
 
@@ -58,6 +61,58 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	})
 }
 
+// NOTE: same as requireAuthentication but responds with a json 401 instead of redirecting to the login page
+func (app *application) requireAPIAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAuthenticated(r) {
+			app.apiClientError(w, http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NOTE: for the routes that need a verified email on top of being logged in (makes a db call), must come after
+// requireAuthentication
+func (app *application) requireVerification(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := app.userModel.Get(app.authenticatedUserID(r))
+		if err != nil {
+			app.serverError(w, err)
+			return
+		}
+
+		if !user.Verified {
+			app.sessionManager.Put(r.Context(), "flash", "Please verify your email address before creating snippets.")
+			http.Redirect(w, r, "/account/view", http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NOTE: same as requireVerification but responds with a json 403 instead of redirecting to the account page
+func (app *application) requireAPIVerification(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := app.userModel.Get(app.authenticatedUserID(r))
+		if err != nil {
+			app.apiServerError(w, err)
+			return
+		}
+
+		if !user.Verified {
+			app.apiErrorResponse(w, http.StatusForbidden, "your email address must be verified before creating snippets")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func noSurf(next http.Handler) http.Handler {
 	csrfHandler := nosurf.New(next)
 	csrfHandler.SetBaseCookie(http.Cookie{
@@ -69,9 +124,32 @@ func noSurf(next http.Handler) http.Handler {
 	return csrfHandler
 }
 
+// NOTE: same as noSurf but a failed csrf check gets a json error response (the token goes in the X-CSRF-Token header)
+func (app *application) noSurfAPI(next http.Handler) http.Handler {
+	csrfHandler := nosurf.New(next)
+	csrfHandler.SetBaseCookie(http.Cookie{
+		HttpOnly: true,
+		Path:     "/",
+		Secure:   true,
+	})
+	csrfHandler.SetFailureHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.apiClientError(w, http.StatusBadRequest)
+	}))
+	// NOTE: requests authenticated by an api token don't carry any cookies so there is nothing to forge
+	csrfHandler.ExemptFunc(app.isTokenAuthenticated)
+
+	return csrfHandler
+}
+
 // NOTE: this middleware adds isAuthenticatedContextKey with true or false (also checks if the user exists)
 func (app *application) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// NOTE: already authenticated by authenticateToken
+		if app.isAuthenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		id := app.sessionManager.GetInt(r.Context(), "authenticatedUserID") // NOTE: returns 0 if doesn't exists
 		if id == 0 {
 			next.ServeHTTP(w, r)
@@ -86,9 +164,48 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 
 		if exists {
 			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+			ctx = context.WithValue(ctx, authenticatedUserIDContextKey, id)
 			r = r.WithContext(ctx)
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// NOTE: authenticate-style middleware for the api, checks the "Authorization: Bearer <token>" header.
+// requests without the header fall through to the session based authenticate middleware
+func (app *application) authenticateToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		authorizationHeader := r.Header.Get("Authorization")
+		if authorizationHeader == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, found := strings.CutPrefix(authorizationHeader, "Bearer ")
+		if !found || token == "" {
+			app.invalidAPIToken(w)
+			return
+		}
+
+		id, err := app.apiTokenModel.Authenticate(token)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCredentials) {
+				app.invalidAPIToken(w)
+			} else {
+				app.apiServerError(w, err)
+			}
+
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+		ctx = context.WithValue(ctx, authenticatedUserIDContextKey, id)
+		ctx = context.WithValue(ctx, isTokenAuthenticatedContextKey, true)
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/harshk200/snippetbox/internal/migrations"
+)
+
+var errUsage = errors.New("usage: migrate up|down [n]|status")
+
+// runs the subcommand given after the flags (migrate is the only one for now)
+func runCommand(cfg *config, args []string, w io.Writer) error {
+	if args[0] != "migrate" {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	if len(args) < 2 || !slices.Contains([]string{"up", "down", "status"}, args[1]) {
+		return errUsage
+	}
+
+	if cfg.Storage == storageMemory {
+		return errors.New("migrate needs -storage=sql, the in-memory storage has no schema")
+	}
+
+	n := 1
+	if args[1] == "down" && len(args) > 2 {
+		var err error
+		n, err = strconv.Atoi(args[2])
+		if err != nil || n < 1 {
+			return errUsage
+		}
+	}
+
+	// NOTE: the migrations have multiple statements per file
+	db, err := openDB(cfg.DNS, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[1] {
+	case "up":
+		applied, err := migrations.Up(db.DB, db.dialect.String())
+		for _, m := range applied {
+			fmt.Fprintf(w, "applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Fprintln(w, "already up to date")
+		}
+	case "down":
+		reverted, err := migrations.Down(db.DB, db.dialect.String(), n)
+		for _, m := range reverted {
+			fmt.Fprintf(w, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(reverted) == 0 {
+			fmt.Fprintln(w, "nothing to revert")
+		}
+	case "status":
+		statuses, err := migrations.Statuses(db.DB, db.dialect.String())
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			applied := "pending"
+			if !s.Pending() {
+				applied = "applied " + humanDate(s.Applied)
+			}
+
+			fmt.Fprintf(w, "%04d_%-20s %s\n", s.Version, s.Name, applied)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+// NOTE: only the argument handling, the invalid commands must fail before connecting to the db
+func TestRunCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		storage string
+		wantErr string
+	}{
+		{name: "Unknown command", args: []string{"serve"}, wantErr: `unknown command "serve"`},
+		{name: "Missing action", args: []string{"migrate"}, wantErr: errUsage.Error()},
+		{name: "Unknown action", args: []string{"migrate", "sideways"}, wantErr: errUsage.Error()},
+		{name: "Invalid down count", args: []string{"migrate", "down", "0"}, wantErr: errUsage.Error()},
+		{name: "In-memory storage", args: []string{"migrate", "up"}, storage: storageMemory, wantErr: "migrate needs -storage=sql, the in-memory storage has no schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			if tt.storage != "" {
+				cfg.Storage = tt.storage
+			}
+
+			err := runCommand(cfg, tt.args, io.Discard)
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			assert.Equal(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var errInvalidCursor = errors.New("invalid pagination cursor")
+
+// NOTE: the cursors are rendered as-is in the templates i.e. an empty cursor means there is no such page
+type pagination struct {
+	PrevCursor string
+	NextCursor string
+}
+
+// encodes the snippet ID as an opaque cursor: base64url(8 byte big-endian ID + HMAC-SHA256 of the ID)
+func (app *application) encodeCursor(id int) string {
+	payload := binary.BigEndian.AppendUint64(nil, uint64(id))
+
+	mac := hmac.New(sha256.New, app.secretKey)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(payload))
+}
+
+// returns the snippet ID stored in the cursor or errInvalidCursor if the cursor was tampered with
+func (app *application) decodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) != 8+sha256.Size {
+		return 0, errInvalidCursor
+	}
+
+	payload, signature := b[:8], b[8:]
+
+	mac := hmac.New(sha256.New, app.secretKey)
+	mac.Write(payload)
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return 0, errInvalidCursor
+	}
+
+	id := binary.BigEndian.Uint64(payload)
+	if id == 0 || id > math.MaxInt {
+		return 0, errInvalidCursor
+	}
+
+	return int(id), nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestCursor(t *testing.T) {
+	app := newTestApplication(t)
+
+	cursor := app.encodeCursor(69)
+
+	t.Run("Round trip", func(t *testing.T) {
+		id, err := app.decodeCursor(cursor)
+
+		assert.NilError(t, err)
+		assert.Equal(t, id, 69)
+	})
+
+	// NOTE: flipping a bit of the encoded ID must invalidate the signature
+	b, _ := base64.RawURLEncoding.DecodeString(cursor)
+	b[7] ^= 1
+	tampered := base64.RawURLEncoding.EncodeToString(b)
+
+	otherApp := newTestApplication(t)
+	otherApp.secretKey = []byte("some-other-key")
+
+	tests := []struct {
+		name   string
+		app    *application
+		cursor string
+	}{
+		{name: "Tampered ID", app: app, cursor: tampered},
+		{name: "Different key", app: otherApp, cursor: cursor},
+		{name: "Not base64", app: app, cursor: "!!!"},
+		{name: "Too short", app: app, cursor: cursor[:10]},
+		{name: "Empty", app: app, cursor: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.app.decodeCursor(tt.cursor)
+
+			assert.Equal(t, err, errInvalidCursor)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinas/alice"
+)
+
+// NOTE: how often the buckets that aren't needed anymore are dropped
+const bucketEvictInterval = time.Minute
+
+// token bucket rate limiting per key (the client's ip or the logged in user). every key gets a bucket of burst tokens
+// that refills at rate tokens per second and every request takes one
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time // NOTE: when the tokens were last topped up
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// takes a token from the key's bucket. when the bucket is empty it returns false and how long until there is a token
+func (rl *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.last).Seconds()*rl.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rl.rate * float64(time.Second))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+// drops the buckets that have refilled completely (those are no different from a new one) and returns how many
+func (rl *rateLimiter) evict(now time.Time) int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	full := time.Duration(rl.burst / rl.rate * float64(time.Second))
+
+	n := 0
+	for key, b := range rl.buckets {
+		if now.Sub(b.last) >= full {
+			delete(rl.buckets, key)
+			n++
+		}
+	}
+
+	return n
+}
+
+// returns the middleware limiting the requests with rl. the logged in users are limited per user (so they don't share
+// a limit with everyone behind the same ip), everyone else per ip.
+// NOTE: must come after the authenticate middleware (if the route group has it)
+func (app *application) rateLimit(rl *rateLimiter) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "ip:" + clientIP(r)
+			if app.isAuthenticated(r) {
+				key = "user:" + strconv.Itoa(app.authenticatedUserID(r))
+			}
+
+			ok, wait := rl.allow(key, time.Now())
+			if !ok {
+				app.tooManyRequests(w, r, wait)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// returns the middleware limiting the requests with rl per ip only. it's cheap (no session or db lookups) so it goes
+// in front of the session and authentication middleware, a client flooding the app is turned away before it costs
+// us any of those. the per route group limits after authenticate still apply
+func (app *application) rateLimitIP(rl *rateLimiter) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := rl.allow("ip:"+clientIP(r), time.Now())
+			if !ok {
+				app.tooManyRequests(w, r, wait)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// the 429 response of the rate limits, json for the api
+func (app *application) tooManyRequests(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	// NOTE: rounded up, Retry-After is in whole seconds
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		app.apiClientError(w, http.StatusTooManyRequests)
+		return
+	}
+
+	app.clientError(w, http.StatusTooManyRequests)
+}
+
+// drops the stale buckets of the limiters every interval, until ctx is cancelled
+func (app *application) evictBuckets(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, rl := range []*rateLimiter{app.ipLimiter, app.dynamicLimiter, app.protectedLimiter, app.staticLimiter} {
+				rl.evict(now)
+			}
+		}
+	}
+}
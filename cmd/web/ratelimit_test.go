@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	rl := newRateLimiter(2, 3)
+
+	now := time.Now()
+
+	// NOTE: the burst goes through right away
+	for range 3 {
+		ok, _ := rl.allow("a", now)
+		assert.Equal(t, ok, true)
+	}
+
+	ok, wait := rl.allow("a", now)
+	assert.Equal(t, ok, false)
+	assert.Equal(t, wait, 500*time.Millisecond)
+
+	// NOTE: the other keys have their own bucket
+	ok, _ = rl.allow("b", now)
+	assert.Equal(t, ok, true)
+
+	ok, _ = rl.allow("a", now.Add(500*time.Millisecond))
+	assert.Equal(t, ok, true)
+
+	ok, wait = rl.allow("a", now.Add(750*time.Millisecond))
+	assert.Equal(t, ok, false)
+	assert.Equal(t, wait, 250*time.Millisecond)
+
+	// NOTE: refills up to the burst and no further
+	for range 3 {
+		ok, _ = rl.allow("a", now.Add(time.Hour))
+		assert.Equal(t, ok, true)
+	}
+
+	ok, _ = rl.allow("a", now.Add(time.Hour))
+	assert.Equal(t, ok, false)
+}
+
+func TestRateLimiterEvict(t *testing.T) {
+	rl := newRateLimiter(1, 10)
+
+	now := time.Now()
+
+	rl.allow("idle", now)
+	rl.allow("busy", now.Add(5*time.Second))
+
+	// NOTE: the idle one has refilled after 10s, the busy one hasn't yet
+	assert.Equal(t, rl.evict(now.Add(10*time.Second)), 1)
+	assert.Equal(t, len(rl.buckets), 1)
+
+	_, ok := rl.buckets["busy"]
+	assert.Equal(t, ok, true)
+
+	assert.Equal(t, rl.evict(now.Add(15*time.Second)), 1)
+	assert.Equal(t, len(rl.buckets), 0)
+}
+
+func TestRateLimit(t *testing.T) {
+	app := newTestApplication(t)
+	app.dynamicLimiter = newRateLimiter(1.0/60, 2)
+	app.staticLimiter = newRateLimiter(1.0/60, 1)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	for range 2 {
+		code, _, _ := ts.get(t, "/")
+		assert.Equal(t, code, http.StatusOK)
+	}
+
+	code, header, _ := ts.get(t, "/")
+	assert.Equal(t, code, http.StatusTooManyRequests)
+	assert.Equal(t, header.Get("Retry-After"), "60")
+
+	t.Run("API", func(t *testing.T) {
+		code, header, body := ts.get(t, "/api/v1/snippets")
+		assert.Equal(t, code, http.StatusTooManyRequests)
+		assert.Equal(t, header.Get("Retry-After"), "60")
+		assert.StringContains(t, body, `"error"`)
+	})
+
+	t.Run("Static", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/static/css/main.css")
+		assert.Equal(t, code, http.StatusOK)
+
+		code, _, _ = ts.get(t, "/static/css/main.css")
+		assert.Equal(t, code, http.StatusTooManyRequests)
+	})
+
+	t.Run("Per ip in front of the session", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.ipLimiter = newRateLimiter(1.0/60, 1)
+
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, header, _ := ts.get(t, "/user/login")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, header.Get("Set-Cookie"), "csrf_token")
+
+		// NOTE: turned away before noSurf (or the session) ever ran, so no cookie
+		code, header, _ = ts.get(t, "/user/login")
+		assert.Equal(t, code, http.StatusTooManyRequests)
+		assert.Equal(t, header.Get("Retry-After"), "60")
+		assert.Equal(t, header.Get("Set-Cookie"), "")
+
+		code, _, _ = ts.get(t, "/api/v1/snippets")
+		assert.Equal(t, code, http.StatusTooManyRequests)
+
+		// NOTE: the static files have their own limit
+		code, _, _ = ts.get(t, "/static/css/main.css")
+		assert.Equal(t, code, http.StatusOK)
+	})
+
+	t.Run("Logged in", func(t *testing.T) {
+		app.dynamicLimiter = newRateLimiter(1.0/60, 1000)
+		app.protectedLimiter = newRateLimiter(1.0/60, 1)
+
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t)
+
+		code, _, _ := ts.get(t, "/account/view")
+		assert.Equal(t, code, http.StatusOK)
+
+		code, _, _ = ts.get(t, "/account/view")
+		assert.Equal(t, code, http.StatusTooManyRequests)
+
+		// NOTE: the protected routes don't use up the limit of the public ones
+		code, _, _ = ts.get(t, "/")
+		assert.Equal(t, code, http.StatusOK)
+	})
+}
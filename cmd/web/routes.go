@@ -2,6 +2,7 @@ package main
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/harshk200/snippetbox/ui"
 	"github.com/julienschmidt/httprouter"
@@ -13,33 +14,86 @@ func (app *application) routes() http.Handler {
 
 	// NOTE: renders a custom
 	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			app.apiNotFound(w)
+			return
+		}
+
 		app.notFound(w)
 	})
 
 	// setting up the static routes
 	fileServer := http.FileServer(http.FS(ui.Files))
-	router.Handler(http.MethodGet, "/static/*filepath", fileServer)
+	// NOTE: limited per ip, there's no session here
+	router.Handler(http.MethodGet, "/static/*filepath", app.rateLimit(app.staticLimiter)(fileServer))
 
 	// NOTE: testing routes
 	router.HandlerFunc(http.MethodGet, "/ping", ping)
 
-	// NOTE: middleware for session management
-	dynamic := alice.New(app.sessionManager.LoadAndSave, noSurf, app.authenticate)
+	// NOTE: middleware for session management. each route group gets its own rate limit, which comes after
+	// authenticate so that the logged in users are limited per user instead of per ip. the per ip limit in front
+	// turns a flood away before it costs a session or db lookup
+	session := alice.New(app.rateLimitIP(app.ipLimiter), app.sessionManager.LoadAndSave, noSurf, app.authenticate)
+
+	dynamic := session.Append(app.rateLimit(app.dynamicLimiter))
 
 	router.Handler(http.MethodGet, "/", dynamic.ThenFunc(app.home))
 	router.Handler(http.MethodGet, "/snippet/view/:id", dynamic.ThenFunc(app.snippetView))
+	router.Handler(http.MethodPost, "/snippet/view/:id", dynamic.ThenFunc(app.snippetUnlockPost))
+	router.Handler(http.MethodGet, "/snippet/view/:id/history", dynamic.ThenFunc(app.snippetHistory))
+	router.Handler(http.MethodGet, "/snippet/view/:id/diff", dynamic.ThenFunc(app.snippetDiff))
+	router.Handler(http.MethodGet, "/snippet/view/:id/forks", dynamic.ThenFunc(app.snippetForks))
+	router.Handler(http.MethodGet, "/snippet/raw/:id", dynamic.ThenFunc(app.snippetRaw))
+	router.Handler(http.MethodGet, "/snippet/download/:id", dynamic.ThenFunc(app.snippetDownload))
+	router.Handler(http.MethodGet, "/snippets", dynamic.ThenFunc(app.snippetArchive))
+	router.Handler(http.MethodGet, "/search", dynamic.ThenFunc(app.snippetSearch))
 	router.Handler(http.MethodGet, "/user/signup", dynamic.ThenFunc(app.userSignup))
 	router.Handler(http.MethodPost, "/user/signup", dynamic.ThenFunc(app.userSignupPost))
 	router.Handler(http.MethodGet, "/user/login", dynamic.ThenFunc(app.userLogin))
 	router.Handler(http.MethodPost, "/user/login", dynamic.ThenFunc(app.userLoginPost))
+	router.Handler(http.MethodGet, "/user/password/forgot", dynamic.ThenFunc(app.userPasswordForgot))
+	router.Handler(http.MethodPost, "/user/password/forgot", dynamic.ThenFunc(app.userPasswordForgotPost))
+	router.Handler(http.MethodGet, "/user/password/reset", dynamic.ThenFunc(app.userPasswordReset))
+	router.Handler(http.MethodPost, "/user/password/reset", dynamic.ThenFunc(app.userPasswordResetPost))
+	router.Handler(http.MethodGet, "/user/verify", dynamic.ThenFunc(app.userVerify))
 
 	// NOTE: protected routes i.e. requires authentication (the middleware makes a db call)
-	protected := dynamic.Append(app.requireAuthentication)
+	protected := session.Append(app.requireAuthentication, app.rateLimit(app.protectedLimiter))
 
-	router.Handler(http.MethodGet, "/snippet/create", protected.ThenFunc(app.snippetCreate))
-	router.Handler(http.MethodPost, "/snippet/create", protected.ThenFunc(app.snippetCreatePost))
+	router.Handler(http.MethodGet, "/snippet/edit/:id", protected.ThenFunc(app.snippetEdit))
+	router.Handler(http.MethodPost, "/snippet/edit/:id", protected.ThenFunc(app.snippetEditPost))
+	router.Handler(http.MethodPost, "/snippet/delete/:id", protected.ThenFunc(app.snippetDeletePost))
+	router.Handler(http.MethodPost, "/snippet/restore/:id", protected.ThenFunc(app.snippetRestorePost))
+	router.Handler(http.MethodGet, "/user/snippets", protected.ThenFunc(app.userSnippets))
+	router.Handler(http.MethodGet, "/account/view", protected.ThenFunc(app.accountView))
+	router.Handler(http.MethodGet, "/account/password/update", protected.ThenFunc(app.accountPasswordUpdate))
+	router.Handler(http.MethodPost, "/account/password/update", protected.ThenFunc(app.accountPasswordUpdatePost))
+	router.Handler(http.MethodPost, "/account/verification/resend", protected.ThenFunc(app.accountVerificationResendPost))
+	router.Handler(http.MethodGet, "/account/tokens", protected.ThenFunc(app.accountTokens))
+	router.Handler(http.MethodPost, "/account/tokens", protected.ThenFunc(app.accountTokensPost))
+	router.Handler(http.MethodPost, "/account/tokens/delete/:id", protected.ThenFunc(app.accountTokenDeletePost))
 	router.Handler(http.MethodPost, "/user/logout", protected.ThenFunc(app.userLogout))
 
+	// NOTE: creating snippets also needs a verified email
+	verified := protected.Append(app.requireVerification)
+
+	router.Handler(http.MethodGet, "/snippet/create", verified.ThenFunc(app.snippetCreate))
+	router.Handler(http.MethodPost, "/snippet/create", verified.ThenFunc(app.snippetCreatePost))
+
+	// NOTE: json api routes, authenticated either by an api token (Authorization: Bearer) or the session cookie
+	apiSession := alice.New(app.rateLimitIP(app.ipLimiter), app.sessionManager.LoadAndSave, app.authenticateToken, app.noSurfAPI, app.authenticate)
+
+	api := apiSession.Append(app.rateLimit(app.dynamicLimiter))
+
+	router.Handler(http.MethodGet, "/api/v1/snippets", api.ThenFunc(app.apiSnippetList))
+	router.Handler(http.MethodGet, "/api/v1/snippets/:id", api.ThenFunc(app.apiSnippetView))
+
+	apiProtected := apiSession.Append(app.requireAPIAuthentication, app.rateLimit(app.protectedLimiter))
+
+	apiVerified := apiProtected.Append(app.requireAPIVerification)
+
+	router.Handler(http.MethodPost, "/api/v1/snippets", apiVerified.ThenFunc(app.apiSnippetCreate))
+
 	// NOTE: this router takes all manages all requests
 	standard := alice.New(app.recoverPanic, app.logRequest, secureHeaders)
 
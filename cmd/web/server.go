@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// serves until ctx is cancelled (i.e. SIGINT or SIGTERM) and then shuts down gracefully: stops accepting new
+// connections, drains the in-flight requests and waits for the background goroutines. both phases share the
+// shutdown timeout
+func (app *application) serve(ctx context.Context, srv *http.Server, certFile, keyFile string, shutdownTimeout time.Duration) error {
+	shutdownErr := make(chan error, 1)
+
+	go func() {
+		<-ctx.Done()
+		app.infoLog.Printf("Shutdown signal received, shutting down (timeout %s)", shutdownTimeout)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		app.infoLog.Println("Draining in-flight requests")
+		err := srv.Shutdown(shutdownCtx)
+		if err != nil {
+			shutdownErr <- err
+			return
+		}
+
+		app.infoLog.Println("Waiting for background tasks to finish")
+		shutdownErr <- app.waitBackground(shutdownCtx)
+	}()
+
+	app.infoLog.Printf("Starting server on port %s\n", srv.Addr)
+	err := srv.ListenAndServeTLS(certFile, keyFile)
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err // NOTE: the server never started (e.g. the port is taken or the certs are missing)
+	}
+
+	return <-shutdownErr
+}
+
+// waits for the goroutines started with app.background, or until ctx is done
+func (app *application) waitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+
+	go func() {
+		app.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.New("timed out waiting for background tasks")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestWaitBackground(t *testing.T) {
+	t.Run("Finished tasks", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		app.background(func() {
+			time.Sleep(10 * time.Millisecond)
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		assert.NilError(t, app.waitBackground(ctx))
+	})
+
+	t.Run("Timed out", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		release := make(chan struct{})
+		defer close(release)
+
+		app.background(func() {
+			<-release
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.Equal(t, app.waitBackground(ctx) != nil, true)
+	})
+
+	t.Run("Recovered panic", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		app.background(func() {
+			panic("oops")
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		assert.NilError(t, app.waitBackground(ctx))
+	})
+}
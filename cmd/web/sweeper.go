@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// NOTE: rows deleted per statement so a big purge doesn't hold the locks for too long
+const sweepBatchSize = 1000
+
+// runs fn in a goroutine that's tracked by app.wg (so main can wait for it) and recovers it's panics
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		defer func() {
+			if err := recover(); err != nil {
+				app.errorLog.Output(2, fmt.Sprintf("%s\n%s", err, debug.Stack()))
+			}
+		}()
+
+		fn()
+	}()
+}
+
+// deletes the expired snippets (older than the grace period), sessions, password reset tokens and the stale login
+// attempts right away and then every interval, until ctx is cancelled
+func (app *application) sweepExpired(ctx context.Context, interval, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		app.sweep(ctx, grace)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// does a single sweep, deleting in batches until there is nothing left (or ctx is cancelled)
+func (app *application) sweep(ctx context.Context, grace time.Duration) {
+	snippets, err := deleteInBatches(ctx, func() (int, error) {
+		return app.snippetModel.DeleteExpired(grace, sweepBatchSize)
+	})
+	if err != nil {
+		app.errorLog.Printf("sweeper: deleting expired snippets: %s", err)
+	}
+
+	sessions, err := deleteInBatches(ctx, func() (int, error) {
+		return app.sessionModel.DeleteExpired(sweepBatchSize)
+	})
+	if err != nil {
+		app.errorLog.Printf("sweeper: deleting expired sessions: %s", err)
+	}
+
+	resets, err := deleteInBatches(ctx, func() (int, error) {
+		return app.passwordResetModel.DeleteExpired(sweepBatchSize)
+	})
+	if err != nil {
+		app.errorLog.Printf("sweeper: deleting expired password reset tokens: %s", err)
+	}
+
+	// NOTE: the failed logins nobody has to wait for anymore
+	logins, err := deleteInBatches(ctx, func() (int, error) {
+		return app.loginAttemptModel.DeleteStale(app.loginThrottle.lockout, sweepBatchSize)
+	})
+	if err != nil {
+		app.errorLog.Printf("sweeper: deleting stale login attempts: %s", err)
+	}
+
+	if snippets > 0 || sessions > 0 || resets > 0 || logins > 0 {
+		app.infoLog.Printf("Sweeper deleted %d expired snippets, %d expired sessions, %d expired password reset tokens and %d stale login attempts", snippets, sessions, resets, logins)
+	}
+}
+
+// calls deleteBatch until it deletes less than a full batch and returns the total deleted
+func deleteInBatches(ctx context.Context, deleteBatch func() (int, error)) (int, error) {
+	total := 0
+
+	for ctx.Err() == nil {
+		n, err := deleteBatch()
+		total += n
+		if err != nil {
+			return total, err
+		}
+
+		if n < sweepBatchSize {
+			break
+		}
+	}
+
+	return total, nil
+}
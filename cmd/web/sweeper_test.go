@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestDeleteInBatches(t *testing.T) {
+	t.Run("Until a partial batch", func(t *testing.T) {
+		batches := []int{sweepBatchSize, sweepBatchSize, 3, sweepBatchSize}
+		calls := 0
+
+		total, err := deleteInBatches(context.Background(), func() (int, error) {
+			n := batches[calls]
+			calls++
+			return n, nil
+		})
+
+		assert.NilError(t, err)
+		assert.Equal(t, total, 2*sweepBatchSize+3)
+		assert.Equal(t, calls, 3)
+	})
+
+	t.Run("Stops on error", func(t *testing.T) {
+		calls := 0
+
+		total, err := deleteInBatches(context.Background(), func() (int, error) {
+			calls++
+			return 0, errors.New("db is down")
+		})
+
+		assert.Equal(t, err != nil, true)
+		assert.Equal(t, total, 0)
+		assert.Equal(t, calls, 1)
+	})
+
+	t.Run("Stops when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+
+		total, err := deleteInBatches(ctx, func() (int, error) {
+			calls++
+			cancel()
+			return sweepBatchSize, nil
+		})
+
+		assert.NilError(t, err)
+		assert.Equal(t, total, sweepBatchSize)
+		assert.Equal(t, calls, 1)
+	})
+}
+
+func TestSweepExpired(t *testing.T) {
+	app := newTestApplication(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	app.background(func() {
+		app.sweepExpired(ctx, time.Millisecond, time.Hour)
+	})
+
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		app.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sweeper didn't stop after the context was cancelled")
+	}
+}
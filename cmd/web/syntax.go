@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+type language struct {
+	Value string // NOTE: the chroma lexer name, this is what gets stored in the db
+	Name  string
+	Ext   string // NOTE: the file extension of downloaded snippets
+}
+
+// the languages a snippet can be highlighted as (shown in this order in the create form)
+var supportedLanguages = []language{
+	{Value: "plaintext", Name: "Plain text", Ext: ".txt"},
+	{Value: "bash", Name: "Bash", Ext: ".sh"},
+	{Value: "c", Name: "C", Ext: ".c"},
+	{Value: "cpp", Name: "C++", Ext: ".cpp"},
+	{Value: "css", Name: "CSS", Ext: ".css"},
+	{Value: "diff", Name: "Diff", Ext: ".diff"},
+	{Value: "dockerfile", Name: "Dockerfile", Ext: ".dockerfile"},
+	{Value: "go", Name: "Go", Ext: ".go"},
+	{Value: "html", Name: "HTML", Ext: ".html"},
+	{Value: "java", Name: "Java", Ext: ".java"},
+	{Value: "javascript", Name: "JavaScript", Ext: ".js"},
+	{Value: "json", Name: "JSON", Ext: ".json"},
+	{Value: "markdown", Name: "Markdown", Ext: ".md"},
+	{Value: "php", Name: "PHP", Ext: ".php"},
+	{Value: "python", Name: "Python", Ext: ".py"},
+	{Value: "ruby", Name: "Ruby", Ext: ".rb"},
+	{Value: "rust", Name: "Rust", Ext: ".rs"},
+	{Value: "sql", Name: "SQL", Ext: ".sql"},
+	{Value: "typescript", Name: "TypeScript", Ext: ".ts"},
+	{Value: "yaml", Name: "YAML", Ext: ".yaml"},
+}
+
+// NOTE: used with validator.PermittedValue
+var supportedLanguageValues = func() []string {
+	values := make([]string, len(supportedLanguages))
+	for i, l := range supportedLanguages {
+		values[i] = l.Value
+	}
+
+	return values
+}()
+
+// NOTE: classes instead of inline styles so the strict Content-Security-Policy still applies,
+// the matching stylesheet is ui/static/css/chroma.css. no surrounding <pre> as the templates add their own
+var syntaxFormatter = html.New(html.WithClasses(true), html.PreventSurroundingPre(true))
+
+// returns the content as highlighted (and escaped) html, falls back to the plain escaped content on any error
+func syntaxHighlight(content, lang string) template.HTML {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		return template.HTML(template.HTMLEscapeString(content))
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(content))
+	}
+
+	buf := new(bytes.Buffer)
+
+	err = syntaxFormatter.Format(buf, styles.Fallback, iterator)
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(content))
+	}
+
+	return template.HTML(buf.String())
+}
+
+// returns the display name of the language value (the value itself if it isn't supported)
+func languageName(value string) string {
+	for _, l := range supportedLanguages {
+		if l.Value == value {
+			return l.Name
+		}
+	}
+
+	return value
+}
+
+// returns the file extension for the language value (.txt if it isn't supported)
+func languageExtension(value string) string {
+	for _, l := range supportedLanguages {
+		if l.Value == value {
+			return l.Ext
+		}
+	}
+
+	return ".txt"
+}
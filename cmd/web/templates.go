@@ -5,6 +5,8 @@ import (
 	"io/fs"
 	"net/http"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/harshk200/snippetbox/internal/models"
@@ -13,23 +15,33 @@ import (
 )
 
 type templateData struct {
-	CurrentYear     int
-	Snippet         *models.Snippet
-	Snippets        []*models.Snippet
-	Form            any
-	Flash           string
-	IsAuthenticated bool
-	CSRFToken       string
-	NotFound        bool
+	CurrentYear         int
+	Snippet             *models.Snippet
+	Snippets            []*models.Snippet
+	ForkedFrom          *models.Snippet
+	ForkCount           int
+	Revisions           []*models.Revision
+	Diff                *revisionDiff
+	User                *models.User
+	APITokens           []*models.APIToken
+	NewAPIToken         string
+	Pagination          pagination
+	Form                any
+	Flash               string
+	IsAuthenticated     bool
+	AuthenticatedUserID int
+	CSRFToken           string
+	NotFound            bool
 }
 
 func (app *application) newTemplateData(r *http.Request) *templateData {
 	return &templateData{
-		CurrentYear:     time.Now().Year(),
-		Flash:           app.sessionManager.PopString(r.Context(), "flash"),
-		IsAuthenticated: app.isAuthenticated(r), // NOTE: checking if this request is authenticated
-		CSRFToken:       nosurf.Token(r),
-		NotFound:        false,
+		CurrentYear:         time.Now().Year(),
+		Flash:               app.sessionManager.PopString(r.Context(), "flash"),
+		IsAuthenticated:     app.isAuthenticated(r), // NOTE: checking if this request is authenticated
+		AuthenticatedUserID: app.authenticatedUserID(r),
+		CSRFToken:           nosurf.Token(r),
+		NotFound:            false,
 	}
 }
 
@@ -41,8 +53,45 @@ func humanDate(t time.Time) string {
 	return t.UTC().Format("02 Jan 2006 at 15:04")
 }
 
+// returns the html escaped text with every (case-insensitive) occurrence of the query's terms wrapped in <mark>
+func highlight(text, query string) template.HTML {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	for i := range terms {
+		terms[i] = regexp.QuoteMeta(terms[i])
+	}
+
+	rx, err := regexp.Compile("(?i)" + strings.Join(terms, "|"))
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	var b strings.Builder
+	last := 0
+
+	for _, match := range rx.FindAllStringIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:match[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(template.HTMLEscapeString(text[match[0]:match[1]]))
+		b.WriteString("</mark>")
+		last = match[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(b.String())
+}
+
 var functions = template.FuncMap{
-	"humanDate": humanDate,
+	"humanDate":       humanDate,
+	"highlight":       highlight,
+	"syntaxHighlight": syntaxHighlight,
+	"languageName":    languageName,
+	"languages": func() []language {
+		return supportedLanguages
+	},
 }
 
 func newTemplateCache() (map[string]*template.Template, error) {
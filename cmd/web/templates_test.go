@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -42,3 +43,96 @@ func TestHumanDate(t *testing.T) {
 
 	}
 }
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+	}{
+		{
+			name:  "Single term",
+			text:  "An old silent pond",
+			query: "pond",
+			want:  "An old silent <mark>pond</mark>",
+		},
+		{
+			name:  "Case insensitive",
+			text:  "An old silent pond",
+			query: "OLD",
+			want:  "An <mark>old</mark> silent pond",
+		},
+		{
+			name:  "Multiple terms",
+			text:  "An old silent pond",
+			query: "old pond",
+			want:  "An <mark>old</mark> silent <mark>pond</mark>",
+		},
+		{
+			name:  "Escapes html",
+			text:  "<b>old</b>",
+			query: "old",
+			want:  "&lt;b&gt;<mark>old</mark>&lt;/b&gt;",
+		},
+		{
+			name:  "Regexp characters in query",
+			text:  "a.b and a+b",
+			query: "a+b",
+			want:  "a.b and <mark>a+b</mark>",
+		},
+		{
+			name:  "Empty query",
+			text:  "An old silent pond",
+			query: " ",
+			want:  "An old silent pond",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, string(highlight(tt.text, tt.query)), tt.want)
+		})
+	}
+}
+
+func TestSyntaxHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		language string
+		want     string
+	}{
+		{
+			name:     "Go keyword",
+			content:  "package main",
+			language: "go",
+			want:     `<span class="kn">package</span>`,
+		},
+		{
+			name:     "Escapes html",
+			content:  "<script>",
+			language: "plaintext",
+			want:     "&lt;script&gt;",
+		},
+		{
+			name:     "Unsupported language",
+			content:  "<b>",
+			language: "cobol-9000",
+			want:     "&lt;b&gt;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(syntaxHighlight(tt.content, tt.language))
+
+			assert.StringContains(t, got, tt.want)
+
+			// NOTE: only classes are allowed because of the Content-Security-Policy
+			if strings.Contains(got, "style=") {
+				t.Errorf("got inline styles in %q", got)
+			}
+		})
+	}
+}
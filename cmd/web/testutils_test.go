@@ -5,16 +5,23 @@ import (
 	"html"
 	"io"
 	"log"
+	"mime/quotedprintable"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
+	"net/mail"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-playground/form/v4"
+	"github.com/harshk200/snippetbox/internal/mailer"
+	"github.com/harshk200/snippetbox/internal/models/memory"
 	"github.com/harshk200/snippetbox/internal/models/mocks"
 )
 
@@ -26,21 +33,55 @@ func newTestApplication(t *testing.T) *application {
 
 	formDecoder := form.NewDecoder()
 
-	sessionManager := scs.SessionManager{}
+	sessionManager := scs.New() // NOTE: uses the in-memory session store by default
 	sessionManager.IdleTimeout = time.Hour * 12
 	sessionManager.Cookie.Secure = true
 
+	// NOTE: the in-memory one instead of a mock as the throttling depends on the failures actually being counted
+	loginAttempts := &memory.LoginAttemptModel{}
+
 	return &application{
-		infoLog:        log.New(io.Discard, "", 0),
-		errorLog:       log.New(io.Discard, "", 0),
-		userModel:      &mocks.UserModel{},
-		snippetModel:   &mocks.SnippetModel{},
-		templateCache:  templateCache,
-		formDecoder:    formDecoder,
-		sessionManager: &sessionManager,
+		infoLog:             log.New(io.Discard, "", 0),
+		errorLog:            log.New(io.Discard, "", 0),
+		userModel:           &mocks.UserModel{},
+		snippetModel:        &mocks.SnippetModel{},
+		apiTokenModel:       &mocks.APITokenModel{},
+		sessionModel:        &mocks.SessionModel{},
+		passwordResetModel:  &mocks.PasswordResetModel{},
+		loginAttemptModel:   loginAttempts,
+		templateCache:       templateCache,
+		formDecoder:         formDecoder,
+		sessionManager:      sessionManager,
+		secretKey:           []byte("test-secret-key"),
+		unlockAttempts:      newFailedAttempts(3, time.Minute),
+		verificationResends: newFailedAttempts(2, time.Minute),
+		resetRequests:       newFailedAttempts(2, time.Minute),
+		loginThrottle:       newLoginThrottle(loginAttempts, 2, time.Minute, 4, time.Hour),
+		ipLimiter:           newRateLimiter(1000, 1000), // NOTE: high enough for the other tests to never hit them
+		dynamicLimiter:      newRateLimiter(1000, 1000),
+		protectedLimiter:    newRateLimiter(1000, 1000),
+		staticLimiter:       newRateLimiter(1000, 1000),
+		mailer:              &mailer.File{Dir: t.TempDir(), Sender: "no-reply@snippetbox.test"},
+		baseURL:             "https://snippetbox.test",
 	}
 }
 
+// NOTE: same as newTestApplication but with the in-memory models instead of the mocks, for the tests that need
+// what they create to actually be there afterwards
+func newTestMemoryApplication(t *testing.T) *application {
+	app := newTestApplication(t)
+
+	users := &memory.UserModel{}
+
+	app.userModel = users
+	app.snippetModel = &memory.SnippetModel{Users: users}
+	app.apiTokenModel = &memory.APITokenModel{}
+	app.sessionModel = &memory.SessionModel{}
+	app.passwordResetModel = &memory.PasswordResetModel{Users: users}
+
+	return app
+}
+
 type testServer struct {
 	*httptest.Server
 }
@@ -105,3 +146,104 @@ func extractCSRFToken(t *testing.T, body string) string {
 	// NOTE: go template by default escapes string and CSRF token is base64 encoded hence UnescapeString
 	return html.UnescapeString(string(matches[1]))
 }
+
+// NOTE: logs in as the mock user (ID 1) so the cookie jar holds an authenticated session for the following requests
+func (ts *testServer) login(t *testing.T) {
+	ts.loginAs(t, "test@example.com", "password")
+}
+
+func (ts *testServer) loginAs(t *testing.T, email, password string) {
+	_, _, body := ts.get(t, "/user/login")
+	csrfToken := extractCSRFToken(t, body)
+
+	form := url.Values{}
+	form.Add("email", email)
+	form.Add("password", password)
+	form.Add("csrf_token", csrfToken)
+
+	code, _, _ := ts.postForm(t, "/user/login", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("login failed with status %d", code)
+	}
+}
+
+// NOTE: sends the json payload as a POST request-body, the extra headers (e.g. X-CSRF-Token) are added to the request
+func (ts *testServer) postJSON(t *testing.T, urlPath string, header http.Header, payload string) (int, http.Header, string) {
+	req, err := http.NewRequest(http.MethodPost, ts.URL+urlPath, strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rs.Body.Close()
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rs.StatusCode, rs.Header, string(bytes.TrimSpace(body))
+}
+
+type sentMail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// NOTE: returns the emails the test application sent (oldest first), waiting for the background goroutines sending
+// them first
+func readMails(t *testing.T, app *application) []sentMail {
+	app.wg.Wait()
+
+	dir := app.mailer.(*mailer.File).Dir
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mails []sentMail
+
+	for _, entry := range entries {
+		f, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		msg, err := mail.ReadMessage(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mails = append(mails, sentMail{To: msg.Header.Get("To"), Subject: msg.Header.Get("Subject"), Body: string(body)})
+	}
+
+	return mails
+}
+
+var mailLinkRX = regexp.MustCompile(`https://snippetbox\.test(/\S+)`)
+
+// NOTE: returns the path of the (first) link to the test application in the email's body
+func extractMailLink(t *testing.T, body string) string {
+	matches := mailLinkRX.FindStringSubmatch(body)
+	if len(matches) < 2 {
+		t.Fatalf("no link found in the email:\n%s", body)
+	}
+
+	return matches[1]
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+// NOTE: slows down the password guessing on the login form. the login attempts are counted per client ip and per email
+// (up front, the successful ones are taken back afterwards), after a few failures every next attempt has to wait twice
+// as long as the one before and after lockoutAfter of them the key is locked out for a while. a blocked attempt
+// doesn't even get to bcrypt, which is what makes a flood of logins expensive for us
+type loginThrottle struct {
+	attempts     models.LoginAttemptModelInterface
+	free         int           // NOTE: failures before the backoff starts
+	backoff      time.Duration // NOTE: the first wait, doubled with every failure after that
+	lockoutAfter int
+	lockout      time.Duration // NOTE: also how long the failures are remembered
+}
+
+func newLoginThrottle(attempts models.LoginAttemptModelInterface, free int, backoff time.Duration, lockoutAfter int, lockout time.Duration) *loginThrottle {
+	return &loginThrottle{
+		attempts:     attempts,
+		free:         free,
+		backoff:      backoff,
+		lockoutAfter: lockoutAfter,
+		lockout:      lockout,
+	}
+}
+
+// the keys the login attempts are counted under.
+// NOTE: the email is hashed so the table doesn't fill up with whatever was typed in (and it fits the column)
+func loginThrottleKeys(ip, email string) []string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+
+	return []string{"ip:" + ip, "email:" + hex.EncodeToString(hash[:])}
+}
+
+// returns how long the key has to wait after failures (0 if it doesn't) and whether it's locked out
+func (lt *loginThrottle) delay(a models.LoginAttempts, now time.Time) (time.Duration, bool) {
+	if a.Failures == 0 || now.Sub(a.LastFailure) >= lt.lockout {
+		return 0, false
+	}
+
+	if a.Failures >= lt.lockoutAfter {
+		return a.LastFailure.Add(lt.lockout).Sub(now), true
+	}
+
+	if a.Failures < lt.free {
+		return 0, false
+	}
+
+	wait := lt.backoff << (a.Failures - lt.free)
+	if wait <= 0 || wait > lt.lockout {
+		wait = lt.lockout // NOTE: overflowed or longer than the lockout itself
+	}
+
+	return max(a.LastFailure.Add(wait).Sub(now), 0), false
+}
+
+// Attempt() counts a login attempt for every key before the password is checked and returns how long it has to wait
+// (0 if it can go ahead) and whether that's a lockout. counting it up front in the same step as the check is what stops
+// a burst of concurrent guesses from all getting past the check before any of them is counted as a failure. the
+// attempts blocked by the earlier failures aren't counted (so waiting it out works) but the ones that lose the race
+// to another attempt are
+func (lt *loginThrottle) Attempt(keys ...string) (time.Duration, bool, error) {
+	var longest time.Duration
+	var locked bool
+
+	now := time.Now()
+	previous := make([]int, len(keys))
+
+	for i, key := range keys {
+		a, err := lt.attempts.Get(key)
+		if err != nil {
+			return 0, false, err
+		}
+
+		wait, isLockout := lt.delay(a, now)
+		if wait > longest {
+			longest, locked = wait, isLockout
+		}
+
+		// NOTE: the count starts over once the last failure is out of the window
+		if now.Sub(a.LastFailure) < lt.lockout {
+			previous[i] = a.Failures
+		}
+	}
+
+	if longest > 0 {
+		return longest, locked, nil
+	}
+
+	for i, key := range keys {
+		a, err := lt.attempts.Fail(key, lt.lockout)
+		if err != nil {
+			return 0, false, err
+		}
+
+		// NOTE: more than this one attempt counted since the check means others got in between. past the free ones
+		// only the first of them goes ahead, the rest wait as if the ones before them had failed
+		if a.Failures > lt.free && a.Failures > previous[i]+1 {
+			wait, isLockout := lt.delay(a, a.LastFailure)
+			if wait > longest {
+				longest, locked = wait, isLockout
+			}
+		}
+	}
+
+	return longest, locked, nil
+}
+
+// Forgive() takes back one counted attempt of the key, for an attempt that turned out to be a successful login
+func (lt *loginThrottle) Forgive(key string) error {
+	return lt.attempts.Forgive(key)
+}
+
+func (lt *loginThrottle) Reset(key string) error {
+	return lt.attempts.Reset(key)
+}
+
+// NOTE: rounded up so the user is never told to come back too early
+func formatWait(d time.Duration) string {
+	plural := func(n int64, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	if d <= time.Minute {
+		return plural(int64((d+time.Second-1)/time.Second), "second")
+	}
+
+	return plural(int64((d+time.Minute-1)/time.Minute), "minute")
+}
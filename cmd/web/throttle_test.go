@@ -0,0 +1,135 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+	"github.com/harshk200/snippetbox/internal/models"
+	"github.com/harshk200/snippetbox/internal/models/memory"
+)
+
+func TestLoginThrottleDelay(t *testing.T) {
+	lt := newLoginThrottle(&memory.LoginAttemptModel{}, 3, time.Second, 10, 15*time.Minute)
+
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		failures   int
+		ago        time.Duration
+		wantWait   time.Duration
+		wantLocked bool
+	}{
+		{name: "No failures", failures: 0, wantWait: 0},
+		{name: "Free failures", failures: 2, wantWait: 0},
+		{name: "First backoff", failures: 3, wantWait: time.Second},
+		{name: "Doubled", failures: 5, wantWait: 4 * time.Second},
+		{name: "Partly waited", failures: 5, ago: 3 * time.Second, wantWait: time.Second},
+		{name: "Fully waited", failures: 5, ago: time.Minute, wantWait: 0},
+		{name: "Locked out", failures: 10, ago: 5 * time.Minute, wantWait: 10 * time.Minute, wantLocked: true},
+		{name: "Lockout over", failures: 12, ago: 15 * time.Minute, wantWait: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := models.LoginAttempts{Failures: tt.failures, LastFailure: now.Add(-tt.ago)}
+
+			wait, locked := lt.delay(a, now)
+
+			assert.Equal(t, wait, tt.wantWait)
+			assert.Equal(t, locked, tt.wantLocked)
+		})
+	}
+}
+
+func TestLoginThrottleAttempt(t *testing.T) {
+	lt := newLoginThrottle(&memory.LoginAttemptModel{}, 1, time.Minute, 3, time.Hour)
+
+	ip, email := loginThrottleKeys("192.0.2.1", "Alice@Example.com")[0], loginThrottleKeys("192.0.2.1", "alice@example.com ")[1]
+
+	// NOTE: the first one is free, and counted
+	wait, _, err := lt.Attempt(ip, email)
+	assert.NilError(t, err)
+	assert.Equal(t, wait, time.Duration(0))
+
+	wait, locked, err := lt.Attempt(ip, email)
+	assert.NilError(t, err)
+	assert.Equal(t, wait > 58*time.Second, true) // NOTE: the stored failure time is truncated to the second
+	assert.Equal(t, locked, false)
+
+	// NOTE: the longest wait of the keys wins
+	a, err := lt.attempts.Fail(email, lt.lockout)
+	assert.NilError(t, err)
+	_, err = lt.attempts.Fail(email, lt.lockout)
+	assert.NilError(t, err)
+	assert.Equal(t, a.Failures, 2)
+
+	wait, locked, err = lt.Attempt(ip, email)
+	assert.NilError(t, err)
+	assert.Equal(t, wait > 59*time.Minute, true)
+	assert.Equal(t, locked, true)
+
+	assert.NilError(t, lt.Reset(email))
+
+	wait, locked, err = lt.Attempt(ip, email)
+	assert.NilError(t, err)
+	assert.Equal(t, wait > 58*time.Second && wait <= time.Minute, true)
+	assert.Equal(t, locked, false)
+
+	// NOTE: a blocked attempt isn't counted
+	a, err = lt.attempts.Get(ip)
+	assert.NilError(t, err)
+	assert.Equal(t, a.Failures, 1)
+}
+
+func TestLoginThrottleAttemptConcurrently(t *testing.T) {
+	lt := newLoginThrottle(&memory.LoginAttemptModel{}, 3, time.Minute, 10, time.Hour)
+
+	keys := loginThrottleKeys("192.0.2.1", "alice@example.com")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			wait, _, err := lt.Attempt(keys...)
+			assert.NilError(t, err)
+
+			if wait == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// NOTE: only the free ones get through, however many of them passed the check at the same time
+	assert.Equal(t, allowed, 3)
+}
+
+func TestFormatWait(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want string
+	}{
+		{wait: 300 * time.Millisecond, want: "1 second"},
+		{wait: 2 * time.Second, want: "2 seconds"},
+		{wait: time.Minute, want: "60 seconds"},
+		{wait: 61 * time.Second, want: "2 minutes"},
+		{wait: 15 * time.Minute, want: "15 minutes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, formatWait(tt.wait), tt.want)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+func TestVerificationToken(t *testing.T) {
+	app := newTestApplication(t)
+
+	user := &models.User{ID: 2, Email: "unverified@example.com"}
+
+	token := app.encodeVerificationToken(user, time.Now().Add(time.Hour))
+
+	t.Run("Round trip", func(t *testing.T) {
+		id, err := parseVerificationToken(token)
+
+		assert.NilError(t, err)
+		assert.Equal(t, id, 2)
+		assert.Equal(t, app.checkVerificationToken(token, user), true)
+	})
+
+	t.Run("Email case", func(t *testing.T) {
+		assert.Equal(t, app.checkVerificationToken(token, &models.User{ID: 2, Email: "Unverified@Example.com"}), true)
+	})
+
+	// NOTE: flipping a bit of the encoded ID must invalidate the signature
+	b, _ := base64.RawURLEncoding.DecodeString(token)
+	b[7] ^= 1
+	tampered := base64.RawURLEncoding.EncodeToString(b)
+
+	otherApp := newTestApplication(t)
+	otherApp.secretKey = []byte("some-other-key")
+
+	// NOTE: a valid pagination cursor is signed with the same key but isn't a verification token
+	cursor := app.encodeCursor(2)
+
+	tests := []struct {
+		name  string
+		app   *application
+		token string
+		user  *models.User
+	}{
+		{name: "Tampered ID", app: app, token: tampered, user: &models.User{ID: 3, Email: user.Email}},
+		{name: "Different key", app: otherApp, token: token, user: user},
+		{name: "Different email", app: app, token: token, user: &models.User{ID: 2, Email: "other@example.com"}},
+		{name: "Different user", app: app, token: token, user: &models.User{ID: 3, Email: user.Email}},
+		{name: "Expired", app: app, token: app.encodeVerificationToken(user, time.Now().Add(-time.Second)), user: user},
+		{name: "Cursor", app: app, token: cursor, user: user},
+		{name: "Not base64", app: app, token: "!!!", user: user},
+		{name: "Empty", app: app, token: "", user: user},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.app.checkVerificationToken(tt.token, tt.user), false)
+		})
+	}
+}
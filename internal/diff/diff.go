@@ -0,0 +1,305 @@
+// line-based diffs between two texts (Myers' algorithm) grouped into unified diff hunks
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+func (op Op) String() string {
+	switch op {
+	case Delete:
+		return "delete"
+	case Insert:
+		return "insert"
+	default:
+		return "equal"
+	}
+}
+
+// a single line of the diff. Old and New are it's 1-based line numbers in the old and new text (0 when the line
+// isn't in that text)
+type Line struct {
+	Op   Op
+	Text string
+	Old  int
+	New  int
+}
+
+// the prefix of the line in a unified diff
+func (l Line) Prefix() string {
+	switch l.Op {
+	case Delete:
+		return "-"
+	case Insert:
+		return "+"
+	default:
+		return " "
+	}
+}
+
+// a group of changed lines together with the unchanged lines around them
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// returns the "@@ -l,s +l,s @@" header (the ,s is left out when it's 1, same as GNU diff)
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// NOTE: past this many edits the diff just replaces every line, so two large and completely different texts can't
+// make the algorithm eat all the memory (it keeps O(d²) state)
+const maxEdits = 2000
+
+// returns the hunks turning a into b with up to context unchanged lines around the changes (none if they're equal)
+func Hunks(a, b string, context int) []Hunk {
+	lines := Lines(a, b)
+
+	var hunks []Hunk
+	oldLine, newLine := 0, 0 // NOTE: the lines of a and b before lines[i]
+
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// NOTE: the hunk starts context lines before the change and grows as long as the next change is within
+		// 2*context equal lines (otherwise the context around the two would overlap anyway)
+		start := max(i-context, 0)
+		end := i
+
+		for end < len(lines) {
+			if lines[end].Op != Equal {
+				end++
+				continue
+			}
+
+			next := end
+			for next < len(lines) && lines[next].Op == Equal {
+				next++
+			}
+
+			if next == len(lines) || next-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+
+			end = next
+		}
+
+		h := Hunk{
+			OldStart: oldLine - (i - start) + 1,
+			NewStart: newLine - (i - start) + 1,
+			Lines:    lines[start:end],
+		}
+
+		for _, l := range h.Lines {
+			if l.Op != Insert {
+				h.OldLines++
+			}
+			if l.Op != Delete {
+				h.NewLines++
+			}
+		}
+
+		// NOTE: an empty range starts at the line before it
+		if h.OldLines == 0 {
+			h.OldStart--
+		}
+		if h.NewLines == 0 {
+			h.NewStart--
+		}
+
+		hunks = append(hunks, h)
+
+		for _, l := range lines[i:end] {
+			if l.Op != Insert {
+				oldLine++
+			}
+			if l.Op != Delete {
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	return hunks
+}
+
+// returns the unified diff of a and b (with 3 lines of context like diff -u) or "" if they're equal
+func Unified(oldName, newName, a, b string) string {
+	hunks := Hunks(a, b, 3)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, h := range hunks {
+		sb.WriteString(h.Header() + "\n")
+
+		for _, l := range h.Lines {
+			sb.WriteString(l.Prefix() + l.Text + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// returns every line of a and b in diff order i.e. the equal lines with the deleted and inserted lines in between
+func Lines(a, b string) []Line {
+	ops := edits(splitLines(a), splitLines(b))
+
+	lines := make([]Line, len(ops))
+	oldLine, newLine := 0, 0
+
+	for i, l := range ops {
+		if l.Op != Insert {
+			oldLine++
+			l.Old = oldLine
+		}
+		if l.Op != Delete {
+			newLine++
+			l.New = newLine
+		}
+
+		lines[i] = l
+	}
+
+	return lines
+}
+
+// NOTE: the textarea submits \r\n line endings, a trailing newline doesn't start another (empty) line
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+// Myers' O(ND) diff. v[k] is the furthest x reached on diagonal k (x - y = k) and trace[d] is a copy of v after d
+// edits (only the diagonals -d..d, the only ones reachable with d edits) to walk the path back from the end
+func edits(a, b []string) []Line {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return replaceAll(a, b)
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // NOTE: down i.e. an insert
+			} else {
+				x = v[offset+k-1] + 1 // NOTE: right i.e. a delete
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				trace = append(trace, v[offset-d:offset+d+1:offset+d+1])
+				return backtrack(a, b, trace)
+			}
+		}
+
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+
+	return nil // NOTE: unreachable, n+m edits always get there
+}
+
+func backtrack(a, b []string, trace [][]int) []Line {
+	x, y := len(a), len(b)
+	var lines []Line
+
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] } // NOTE: prev holds the diagonals -(d-1)..d-1
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, Line{Op: Equal, Text: a[x-1]})
+			x--
+			y--
+		}
+
+		if x == prevX {
+			lines = append(lines, Line{Op: Insert, Text: b[y-1]})
+			y--
+		} else {
+			lines = append(lines, Line{Op: Delete, Text: a[x-1]})
+			x--
+		}
+	}
+
+	for x > 0 && y > 0 {
+		lines = append(lines, Line{Op: Equal, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	slices.Reverse(lines) // NOTE: collected from the end
+
+	return lines
+}
+
+func replaceAll(a, b []string) []Line {
+	lines := make([]Line, 0, len(a)+len(b))
+
+	for _, text := range a {
+		lines = append(lines, Line{Op: Delete, Text: text})
+	}
+	for _, text := range b {
+		lines = append(lines, Line{Op: Insert, Text: text})
+	}
+
+	return lines
+}
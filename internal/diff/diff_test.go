@@ -0,0 +1,116 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "Equal",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo",
+			want: "",
+		},
+		{
+			name: "Changed line",
+			a:    "one\ntwo\nthree",
+			b:    "one\n2\nthree",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			name: "From empty",
+			a:    "",
+			b:    "one\ntwo",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name: "To empty",
+			a:    "one",
+			b:    "",
+			want: "--- a\n+++ b\n@@ -1 +0,0 @@\n-one\n",
+		},
+		{
+			name: "CRLF",
+			a:    "one\r\ntwo\r\n",
+			b:    "one\ntwo\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,3 @@\n one\n two\n+three\n",
+		},
+		{
+			name: "Separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name: "Merged hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8",
+			b:    "one\n2\n3\n4\n5\n6\n7\neight",
+			want: "--- a\n+++ b\n@@ -1,8 +1,8 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Unified("a", "b", tt.a, tt.b), tt.want)
+		})
+	}
+}
+
+// NOTE: applying the diff to a must give back b, for inputs where the shortest edit isn't obvious
+func TestLinesRoundTrip(t *testing.T) {
+	tests := []struct {
+		a string
+		b string
+	}{
+		{a: "a\nb\nc\na\nb\nb\na", b: "c\nb\na\nb\na\nc"},
+		{a: "x\ny\nx\ny", b: "y\nx\ny\nx"},
+		{a: strings.Repeat("same\n", 50) + "end", b: "start\n" + strings.Repeat("same\n", 49) + "same"},
+	}
+
+	for _, tt := range tests {
+		var oldLines, newLines []string
+		for _, l := range Lines(tt.a, tt.b) {
+			if l.Op != Insert {
+				oldLines = append(oldLines, l.Text)
+			}
+			if l.Op != Delete {
+				newLines = append(newLines, l.Text)
+			}
+		}
+
+		assert.Equal(t, strings.Join(oldLines, "\n"), tt.a)
+		assert.Equal(t, strings.Join(newLines, "\n"), tt.b)
+	}
+
+	// NOTE: the classic example from Myers' paper, 5 edits is the shortest
+	changes := 0
+	for _, l := range Lines("a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc") {
+		if l.Op != Equal {
+			changes++
+		}
+	}
+	assert.Equal(t, changes, 5)
+}
+
+func TestLinesTooManyEdits(t *testing.T) {
+	var a, b strings.Builder
+	for i := range maxEdits {
+		a.WriteString("a" + strings.Repeat("x", i%7) + "\n")
+		b.WriteString("b" + strings.Repeat("x", i%7) + "\n")
+	}
+
+	lines := Lines(a.String(), b.String())
+
+	assert.Equal(t, len(lines), 2*maxEdits)
+	assert.Equal(t, lines[0].Op, Delete)
+	assert.Equal(t, lines[maxEdits].Op, Insert)
+}
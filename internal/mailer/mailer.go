@@ -0,0 +1,128 @@
+// Package mailer sends the plain text emails of the app (e.g. the password reset links). the app only depends on the
+// Mailer interface so the transport can be swapped: SMTP in production, File for development and the tests
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Mailer interface {
+	Send(recipient, subject, body string) error
+}
+
+// sends the emails through an SMTP server. the auth is skipped when there is no username
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Sender   string
+}
+
+func (m *SMTP) Send(recipient, subject, body string) error {
+	msg, err := message(m.Sender, recipient, subject, body)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		// NOTE: net/smtp refuses to send the password unless the connection is TLS (or to localhost)
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	from, err := mail.ParseAddress(m.Sender)
+	if err != nil {
+		return fmt.Errorf("mailer: invalid sender: %w", err)
+	}
+
+	to, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return fmt.Errorf("mailer: invalid recipient: %w", err)
+	}
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+
+	return smtp.SendMail(addr, auth, from.Address, []string{to.Address}, msg)
+}
+
+// writes every email to it's own .eml file in Dir instead of sending it (any mail client can open them)
+type File struct {
+	Dir    string
+	Sender string
+}
+
+func (m *File) Send(recipient, subject, body string) error {
+	msg, err := message(m.Sender, recipient, subject, body)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(m.Dir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: the timestamp keeps them in the order they were sent, the random suffix makes the names unique
+	suffix := make([]byte, 4)
+	_, err = rand.Read(suffix)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	// NOTE: the emails hold one-time links so only the owner can read them
+	return os.WriteFile(filepath.Join(m.Dir, name), msg, 0o600)
+}
+
+// builds the RFC 5322 message. the body is quoted-printable so any utf-8 text (and long lines) go through as-is
+func message(sender, recipient, subject, body string) ([]byte, error) {
+	// NOTE: a newline in a header would let whoever controls the value add headers (or recipients) of their own
+	for _, value := range []string{sender, recipient, subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errors.New("mailer: header values cannot contain newlines")
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", sender)
+	fmt.Fprintf(&buf, "To: %s\r\n", recipient)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	_, err := w.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,169 @@
+package mailer
+
+import (
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+// NOTE: just enough of an SMTP server for net/smtp to deliver a single message to it
+type smtpStandIn struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     []byte
+	done     chan error
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	s := &smtpStandIn{listener: l, done: make(chan error, 1)}
+
+	go func() {
+		s.done <- s.serve()
+	}()
+
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) serve() error {
+	c, err := s.listener.Accept()
+	if err != nil {
+		return err
+	}
+
+	conn := textproto.NewConn(c)
+	defer conn.Close()
+
+	err = conn.PrintfLine("220 localhost ready")
+	if err != nil {
+		return err
+	}
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			err = conn.PrintfLine("250 localhost")
+		case "MAIL":
+			s.from = arg
+			err = conn.PrintfLine("250 OK")
+		case "RCPT":
+			s.to = append(s.to, arg)
+			err = conn.PrintfLine("250 OK")
+		case "DATA":
+			err = conn.PrintfLine("354 go ahead")
+			if err != nil {
+				return err
+			}
+
+			s.data, err = conn.ReadDotBytes()
+			if err != nil {
+				return err
+			}
+
+			err = conn.PrintfLine("250 OK")
+		case "QUIT":
+			return conn.PrintfLine("221 bye")
+		default:
+			err = conn.PrintfLine("502 not implemented")
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// returns the subject and the decoded body of the raw message
+func parseMessage(t *testing.T, raw []byte) (string, string) {
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return msg.Header.Get("Subject"), string(body)
+}
+
+func TestSMTP(t *testing.T) {
+	s := newSMTPStandIn(t)
+
+	m := &SMTP{Host: "127.0.0.1", Port: s.port(), Sender: "Snippetbox <no-reply@example.com>"}
+
+	err := m.Send("alice@example.com", "Reset your password", "Hi Alice,\nhttps://example.com/reset?token=ABC\n")
+	assert.NilError(t, err)
+	assert.NilError(t, <-s.done)
+
+	assert.Equal(t, s.from, "FROM:<no-reply@example.com>")
+	assert.Equal(t, strings.Join(s.to, ","), "TO:<alice@example.com>")
+
+	subject, body := parseMessage(t, s.data)
+	assert.Equal(t, subject, "Reset your password")
+	// NOTE: ReadDotBytes turns the CRLFs back into LFs
+	assert.Equal(t, body, "Hi Alice,\nhttps://example.com/reset?token=ABC\n")
+}
+
+func TestFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m := &File{Dir: dir, Sender: "no-reply@example.com"}
+
+	for i := range 2 {
+		err := m.Send("alice@example.com", "Message "+strconv.Itoa(i), "body")
+		assert.NilError(t, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+
+	// NOTE: the names sort in the order the emails were sent
+	raw, err := os.ReadFile(filepath.Join(dir, entries[1].Name()))
+	assert.NilError(t, err)
+
+	subject, body := parseMessage(t, raw)
+	assert.Equal(t, subject, "Message 1")
+	assert.Equal(t, body, "body")
+}
+
+func TestHeaderInjection(t *testing.T) {
+	m := &File{Dir: t.TempDir(), Sender: "no-reply@example.com"}
+
+	err := m.Send("alice@example.com\r\nBcc: mallory@example.com", "subject", "body")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	entries, err := os.ReadDir(m.Dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+}
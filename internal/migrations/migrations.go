@@ -0,0 +1,259 @@
+// the versioned database schema. the migrations are embedded sql files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, one directory per dialect (mysql, postgres and sqlite) with the same versions in each.
+// the applied versions are recorded in the schema_migrations table.
+// NOTE: each file can hold multiple statements so a mysql connection needs multiStatements=true
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed "sql"
+var files embed.FS
+
+var fileRX = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// a migration together with when it was applied (the zero time if it's still pending)
+type Status struct {
+	Migration
+	Applied time.Time
+}
+
+func (s Status) Pending() bool {
+	return s.Applied.IsZero()
+}
+
+// returns all the migrations of the dialect sorted by version
+func All(dialect string) ([]Migration, error) {
+	dir := "sql/" + dialect
+
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		matches := fileRX.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("migrations: invalid file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+
+		script, err := fs.ReadFile(files, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return nil, fmt.Errorf("migrations: version %d is used by both %q and %q", version, m.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			m.up = string(script)
+		} else {
+			m.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migrations: version %d is missing it's up or down file", m.Version)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+
+	return migrations, nil
+}
+
+// returns every migration with the time it was applied at
+func Statuses(db *sql.DB, dialect string) ([]Status, error) {
+	migrations, err := All(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(db, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = Status{Migration: m, Applied: applied[m.Version]}
+	}
+
+	return statuses, nil
+}
+
+// applies all the pending migrations in order and returns the ones that were applied. each migration runs in a
+// transaction together with recording it's version.
+// NOTE: mysql commits DDL statements implicitly so on mysql a migration that fails halfway isn't rolled back, it's
+// left unrecorded and has to be cleaned up by hand
+func Up(db *sql.DB, dialect string) ([]Migration, error) {
+	statuses, err := Statuses(db, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+
+	for _, s := range statuses {
+		if !s.Pending() {
+			continue
+		}
+
+		stmt := `INSERT INTO schema_migrations (version, name, applied) VALUES (` + placeholders(dialect, 3) + `)`
+
+		err = run(db, s.up, stmt, s.Version, s.Name, time.Now().UTC().Truncate(time.Second))
+		if err != nil {
+			return applied, fmt.Errorf("migrations: applying %d_%s: %w", s.Version, s.Name, err)
+		}
+
+		applied = append(applied, s.Migration)
+	}
+
+	return applied, nil
+}
+
+// reverts the last n applied migrations (newest first) and returns the ones that were reverted
+func Down(db *sql.DB, dialect string, n int) ([]Migration, error) {
+	if n < 1 {
+		return nil, errors.New("migrations: must revert at least one migration")
+	}
+
+	statuses, err := Statuses(db, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+
+	for _, s := range slices.Backward(statuses) {
+		if len(reverted) == n {
+			break
+		}
+
+		if s.Pending() {
+			continue
+		}
+
+		stmt := `DELETE FROM schema_migrations WHERE version = ` + placeholders(dialect, 1)
+
+		err = run(db, s.down, stmt, s.Version)
+		if err != nil {
+			return reverted, fmt.Errorf("migrations: reverting %d_%s: %w", s.Version, s.Name, err)
+		}
+
+		reverted = append(reverted, s.Migration)
+	}
+
+	return reverted, nil
+}
+
+// runs the migration's script and then the statement that records it in a single transaction
+func run(db *sql.DB, script, stmt string, args ...any) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // NOTE: no-op once the transaction is committed
+
+	_, err = tx.Exec(script)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// returns n comma separated placeholders in the dialect's style (postgres numbers them)
+func placeholders(dialect string, n int) string {
+	p := make([]string, n)
+	for i := range p {
+		p[i] = "?"
+		if dialect == "postgres" {
+			p[i] = "$" + strconv.Itoa(i+1)
+		}
+	}
+
+	return strings.Join(p, ", ")
+}
+
+// returns the applied versions and when they were applied (creates the schema_migrations table if needed)
+func appliedVersions(db *sql.DB, dialect string) (map[int]time.Time, error) {
+	timestamp := "DATETIME"
+	if dialect == "postgres" {
+		timestamp = "TIMESTAMP"
+	}
+
+	stmt := `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied ` + timestamp + ` NOT NULL
+)`
+
+	_, err := db.Exec(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+
+	for rows.Next() {
+		var version int
+		var t time.Time
+
+		err = rows.Scan(&version, &t)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[version] = t
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
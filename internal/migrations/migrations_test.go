@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestAll(t *testing.T) {
+	mysql, err := All("mysql")
+	assert.NilError(t, err)
+
+	// NOTE: versions start at 1 and have no gaps so the order is obvious from the file names
+	for i, m := range mysql {
+		assert.Equal(t, m.Version, i+1)
+		assert.Equal(t, strings.TrimSpace(m.up) != "", true)
+		assert.Equal(t, strings.TrimSpace(m.down) != "", true)
+	}
+
+	// NOTE: the scs session stores need the sessions table
+	names := make([]string, len(mysql))
+	for i, m := range mysql {
+		names[i] = m.Name
+	}
+	assert.StringContains(t, strings.Join(names, ","), "create_sessions")
+
+	// NOTE: every dialect has the same migrations so the versions mean the same schema everywhere
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		t.Run(dialect, func(t *testing.T) {
+			migrations, err := All(dialect)
+			assert.NilError(t, err)
+			assert.Equal(t, len(migrations), len(mysql))
+
+			for i, m := range migrations {
+				assert.Equal(t, m.Version, mysql[i].Version)
+				assert.Equal(t, m.Name, mysql[i].Name)
+			}
+		})
+	}
+
+	t.Run("Unknown dialect", func(t *testing.T) {
+		_, err := All("oracle")
+
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -1,3 +1 @@
 DROP TABLE snippets;
-
-DROP TABLE users;
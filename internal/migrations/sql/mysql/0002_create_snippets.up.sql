@@ -0,0 +1,25 @@
+CREATE TABLE snippets (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    slug CHAR(22) CHARACTER SET ascii COLLATE ascii_bin NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    language VARCHAR(20) NOT NULL DEFAULT 'plaintext',
+    visibility ENUM('public', 'unlisted', 'private') NOT NULL DEFAULT 'public',
+    hashed_password CHAR(60),
+    burn_after_reading BOOLEAN NOT NULL DEFAULT FALSE,
+    created DATETIME NOT NULL,
+    expires DATETIME,
+    user_id INTEGER NOT NULL
+);
+
+CREATE INDEX idx_snippets_created ON snippets(created);
+
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
+
+CREATE INDEX idx_snippets_expires ON snippets(expires);
+
+ALTER TABLE snippets ADD CONSTRAINT snippets_uc_slug UNIQUE(slug);
+
+CREATE FULLTEXT INDEX idx_snippets_search ON snippets(title, content);
+
+ALTER TABLE snippets ADD CONSTRAINT fk_snippets_user FOREIGN KEY (user_id) REFERENCES users(id);
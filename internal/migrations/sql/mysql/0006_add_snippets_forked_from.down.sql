@@ -0,0 +1,5 @@
+ALTER TABLE snippets DROP FOREIGN KEY fk_snippets_forked_from;
+
+DROP INDEX idx_snippets_forked_from ON snippets;
+
+ALTER TABLE snippets DROP COLUMN forked_from;
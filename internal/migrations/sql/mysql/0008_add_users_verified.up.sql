@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- NOTE: the existing accounts predate the email verification so they're treated as verified
+UPDATE users SET verified = TRUE;
@@ -0,0 +1,10 @@
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    hashed_password BYTEA NOT NULL,
+    created TIMESTAMP NOT NULL
+);
+
+-- NOTE: on LOWER(email) so emails are unique case-insensitively like with mysql's default collation
+CREATE UNIQUE INDEX users_uc_email ON users(LOWER(email));
@@ -0,0 +1,25 @@
+CREATE TABLE snippets (
+    id SERIAL PRIMARY KEY,
+    slug CHAR(22) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    language VARCHAR(20) NOT NULL DEFAULT 'plaintext',
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted', 'private')),
+    hashed_password BYTEA,
+    burn_after_reading BOOLEAN NOT NULL DEFAULT FALSE,
+    created TIMESTAMP NOT NULL,
+    expires TIMESTAMP,
+    user_id INTEGER NOT NULL
+);
+
+CREATE INDEX idx_snippets_created ON snippets(created);
+
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
+
+CREATE INDEX idx_snippets_expires ON snippets(expires);
+
+ALTER TABLE snippets ADD CONSTRAINT snippets_uc_slug UNIQUE(slug);
+
+CREATE INDEX idx_snippets_search ON snippets USING GIN (to_tsvector('simple', title || ' ' || content));
+
+ALTER TABLE snippets ADD CONSTRAINT fk_snippets_user FOREIGN KEY (user_id) REFERENCES users(id);
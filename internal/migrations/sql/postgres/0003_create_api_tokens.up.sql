@@ -0,0 +1,11 @@
+CREATE TABLE api_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    hash BYTEA NOT NULL,
+    created TIMESTAMP NOT NULL
+);
+
+ALTER TABLE api_tokens ADD CONSTRAINT api_tokens_uc_hash UNIQUE(hash);
+
+ALTER TABLE api_tokens ADD CONSTRAINT fk_api_tokens_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
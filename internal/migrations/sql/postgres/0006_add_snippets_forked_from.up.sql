@@ -0,0 +1,6 @@
+ALTER TABLE snippets ADD COLUMN forked_from INTEGER;
+
+CREATE INDEX idx_snippets_forked_from ON snippets(forked_from);
+
+-- NOTE: a fork outlives the snippet it was forked from, it just stops pointing at it
+ALTER TABLE snippets ADD CONSTRAINT fk_snippets_forked_from FOREIGN KEY (forked_from) REFERENCES snippets(id) ON DELETE SET NULL;
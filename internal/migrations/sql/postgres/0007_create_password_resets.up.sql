@@ -0,0 +1,13 @@
+-- NOTE: only the sha256 hash of the token is stored, the plaintext is in the emailed link
+CREATE TABLE password_resets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    hash BYTEA NOT NULL,
+    expires TIMESTAMP NOT NULL
+);
+
+ALTER TABLE password_resets ADD CONSTRAINT password_resets_uc_hash UNIQUE(hash);
+
+CREATE INDEX idx_password_resets_expires ON password_resets(expires);
+
+ALTER TABLE password_resets ADD CONSTRAINT fk_password_resets_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
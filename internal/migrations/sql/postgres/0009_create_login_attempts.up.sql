@@ -0,0 +1,8 @@
+-- NOTE: the failed logins per key (the client's ip or the email tried), see cmd/web/throttle.go
+CREATE TABLE login_attempts (
+    attempt_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failure TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_login_attempts_last_failure ON login_attempts(last_failure);
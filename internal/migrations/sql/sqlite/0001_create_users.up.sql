@@ -0,0 +1,9 @@
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL COLLATE NOCASE, -- NOTE: case-insensitive like with mysql's default collation
+    hashed_password BLOB NOT NULL,
+    created DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX users_uc_email ON users(email);
@@ -0,0 +1,22 @@
+-- NOTE: sqlite can't add constraints to an existing table so they're all part of the CREATE TABLE
+CREATE TABLE snippets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug CHAR(22) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    language VARCHAR(20) NOT NULL DEFAULT 'plaintext',
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted', 'private')),
+    hashed_password BLOB,
+    burn_after_reading BOOLEAN NOT NULL DEFAULT FALSE,
+    created DATETIME NOT NULL,
+    expires DATETIME,
+    user_id INTEGER NOT NULL,
+    CONSTRAINT snippets_uc_slug UNIQUE(slug),
+    CONSTRAINT fk_snippets_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_snippets_created ON snippets(created);
+
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
+
+CREATE INDEX idx_snippets_expires ON snippets(expires);
@@ -0,0 +1,9 @@
+CREATE TABLE api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    hash BLOB NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT api_tokens_uc_hash UNIQUE(hash),
+    CONSTRAINT fk_api_tokens_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+CREATE TABLE revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snippet_id INTEGER NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT fk_revisions_snippet FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    CONSTRAINT fk_revisions_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_revisions_snippet_id ON revisions(snippet_id);
+
+-- NOTE: the existing snippets start out with their current version as the first revision
+INSERT INTO revisions (snippet_id, title, content, user_id, created)
+SELECT id, title, content, user_id, created FROM snippets ORDER BY id;
@@ -0,0 +1,10 @@
+-- NOTE: sqlite can't drop a column that's part of a foreign key (which the down migration has to do) so a trigger
+-- does what ON DELETE SET NULL does on the other databases
+ALTER TABLE snippets ADD COLUMN forked_from INTEGER;
+
+CREATE INDEX idx_snippets_forked_from ON snippets(forked_from);
+
+CREATE TRIGGER trg_snippets_forked_from AFTER DELETE ON snippets
+BEGIN
+    UPDATE snippets SET forked_from = NULL WHERE forked_from = OLD.id;
+END;
@@ -0,0 +1,11 @@
+-- NOTE: only the sha256 hash of the token is stored, the plaintext is in the emailed link
+CREATE TABLE password_resets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    hash BLOB NOT NULL,
+    expires DATETIME NOT NULL,
+    CONSTRAINT password_resets_uc_hash UNIQUE(hash),
+    CONSTRAINT fk_password_resets_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_password_resets_expires ON password_resets(expires);
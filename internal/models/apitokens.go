@@ -0,0 +1,135 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"time"
+)
+
+type APITokenModelInterface interface {
+	Insert(userID int, name string) (string, error)
+	ByUser(userID int) ([]*APIToken, error)
+	Delete(id, userID int) error
+	Authenticate(plaintext string) (int, error)
+}
+
+// represents a personal api token (the plaintext is never stored, only it's sha256 hash)
+type APIToken struct {
+	ID      int
+	UserID  int
+	Name    string
+	Created time.Time
+}
+
+type APITokenModel struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+// NOTE: sha256 instead of bcrypt because we need to look the token up by it's hash (and the tokens are random anyway).
+// used for the password reset tokens too
+func hashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+// returns a random token (128 bits, base32 so it's safe in a url)
+func newToken() (string, error) {
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes), nil
+}
+
+// creates a new token for the user and returns it's plaintext (the only time the plaintext is available)
+func (m *APITokenModel) Insert(userID int, name string) (string, error) {
+	plaintext, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	stmt := `INSERT INTO api_tokens (user_id, name, hash, created)
+    VALUES(?, ?, ?, ?)`
+
+	_, err = m.DB.Exec(m.Dialect.rebind(stmt), userID, name, hashToken(plaintext), now())
+	if err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+func (m *APITokenModel) ByUser(userID int) ([]*APIToken, error) {
+	query := `SELECT id, user_id, name, created FROM api_tokens
+    WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := m.DB.Query(m.Dialect.rebind(query), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*APIToken{}
+
+	for rows.Next() {
+		t := &APIToken{}
+
+		err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// revokes the token. returns ErrNoRecord if the token doesn't exist or isn't owned by the user
+func (m *APITokenModel) Delete(id, userID int) error {
+	stmt := `DELETE FROM api_tokens WHERE id = ? AND user_id = ?`
+
+	result, err := m.DB.Exec(m.Dialect.rebind(stmt), id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Authenticate() returns the ID of the user the plaintext token belongs to
+func (m *APITokenModel) Authenticate(plaintext string) (int, error) {
+	var userID int
+
+	query := `SELECT user_id FROM api_tokens WHERE hash = ?`
+
+	err := m.DB.QueryRow(m.Dialect.rebind(query), hashToken(plaintext)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidCredentials
+		}
+
+		return 0, err
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// the databases the models can be backed by. the queries are written once with ? placeholders and the
+// dialect takes care of the differences
+type Dialect int
+
+const (
+	MySQL Dialect = iota // NOTE: the zero value, so a model without a Dialect is a mysql one
+	Postgres
+	SQLite
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case Postgres:
+		return "postgres"
+	case SQLite:
+		return "sqlite"
+	default:
+		return "mysql"
+	}
+}
+
+// returns the dialect picked by the DSN's scheme and the data source to pass to it's driver:
+//   - postgres://... or postgresql://... is handed to pgx as-is
+//   - sqlite://path (sqlite:///abs/path for an absolute one or sqlite://:memory:) is a sqlite file
+//   - mysql://... or a DSN without a scheme is a go-sql-driver/mysql DSN
+func ParseDSN(dsn string) (Dialect, string, error) {
+	scheme, rest, found := strings.Cut(dsn, "://")
+	if !found {
+		return MySQL, dsn, nil
+	}
+
+	switch scheme {
+	case "mysql":
+		return MySQL, rest, nil
+	case "postgres", "postgresql":
+		return Postgres, dsn, nil
+	case "sqlite":
+		if rest == "" {
+			return 0, "", errors.New("models: sqlite DSN is missing the path")
+		}
+
+		return SQLite, sqliteDataSource(rest), nil
+	default:
+		return 0, "", fmt.Errorf("models: unsupported DSN scheme %q", scheme)
+	}
+}
+
+// NOTE: enforces foreign keys (off by default in sqlite), waits on a locked db instead of failing right away and
+// starts the transactions as writers so two of them can't both read and then try to write (see SnippetModel.Burn)
+const sqliteParams = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate"
+
+func sqliteDataSource(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + sqliteParams
+	}
+
+	return path + "?" + sqliteParams
+}
+
+// rewrites the ? placeholders to the dialect's own (postgres uses $1, $2, ...).
+// NOTE: none of the queries have a ? inside a string literal so there is no need to parse them
+func (d Dialect) rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// NOTE: satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// runs the INSERT and returns the id of the new row (postgres has no LastInsertId so it uses RETURNING)
+func (d Dialect) insert(q querier, stmt string, args ...any) (int, error) {
+	if d == Postgres {
+		var id int
+
+		err := q.QueryRow(d.rebind(stmt+" RETURNING id"), args...).Scan(&id)
+		if err != nil {
+			return 0, err
+		}
+
+		return id, nil
+	}
+
+	result, err := q.Exec(d.rebind(stmt), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// the row lock for a SELECT inside a transaction. sqlite doesn't have one (and doesn't need it as the transactions
+// are started as writers)
+func (d Dialect) forUpdate() string {
+	if d == SQLite {
+		return ""
+	}
+
+	return " FOR UPDATE"
+}
+
+// the condition to look a user up by email, case-insensitively like mysql's default collation does.
+// NOTE: the sqlite column is COLLATE NOCASE and the postgres unique index is on LOWER(email)
+func (d Dialect) emailEquals() string {
+	if d == Postgres {
+		return "LOWER(email) = LOWER(?)"
+	}
+
+	return "email = ?"
+}
+
+// reports whether err is the unique email constraint of the users table being violated
+func (d Dialect) isDuplicateEmail(err error) bool {
+	var mySQLError *mysql.MySQLError
+	var pgError *pgconn.PgError
+	var sqliteError *sqlite.Error
+
+	switch {
+	case errors.As(err, &mySQLError):
+		return mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "users_uc_email")
+	case errors.As(err, &pgError):
+		return pgError.Code == "23505" && pgError.ConstraintName == "users_uc_email"
+	case errors.As(err, &sqliteError):
+		// NOTE: sqlite names the columns instead of the constraint
+		return sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE && strings.Contains(sqliteError.Error(), "users.email")
+	default:
+		return false
+	}
+}
+
+// returns the current time to pass to the queries (instead of the db's own clock functions which differ per
+// dialect), truncated to seconds like a mysql DATETIME
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// NOTE: only counts the failures, what to do about them (the backoff and the lockout) is up to the caller
+type LoginAttemptModelInterface interface {
+	Get(key string) (LoginAttempts, error)
+	Fail(key string, window time.Duration) (LoginAttempts, error)
+	Forgive(key string) error
+	Reset(key string) error
+	DeleteStale(window time.Duration, limit int) (int, error)
+}
+
+// the failed logins of a key (the zero value when there are none)
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+type LoginAttemptModel struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+func (m *LoginAttemptModel) Get(key string) (LoginAttempts, error) {
+	var a LoginAttempts
+
+	query := `SELECT failures, last_failure FROM login_attempts WHERE attempt_key = ?`
+
+	err := m.DB.QueryRow(m.Dialect.rebind(query), key).Scan(&a.Failures, &a.LastFailure)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return LoginAttempts{}, err
+	}
+
+	return a, nil
+}
+
+// Fail() counts a failure for the key and returns the updated count. the count starts over when the last failure is
+// older than window
+func (m *LoginAttemptModel) Fail(key string, window time.Duration) (LoginAttempts, error) {
+	t := now()
+
+	// NOTE: a single upsert so concurrent failures can't overwrite each others counts. the failures are updated before
+	// last_failure as mysql evaluates the assignments in order
+	var stmt string
+
+	switch m.Dialect {
+	case MySQL:
+		stmt = `INSERT INTO login_attempts (attempt_key, failures, last_failure) VALUES (?, 1, ?)
+    ON DUPLICATE KEY UPDATE
+        failures = CASE WHEN last_failure < ? THEN 1 ELSE failures + 1 END,
+        last_failure = VALUES(last_failure)`
+	default:
+		stmt = `INSERT INTO login_attempts (attempt_key, failures, last_failure) VALUES (?, 1, ?)
+    ON CONFLICT (attempt_key) DO UPDATE SET
+        failures = CASE WHEN login_attempts.last_failure < ? THEN 1 ELSE login_attempts.failures + 1 END,
+        last_failure = excluded.last_failure`
+	}
+
+	_, err := m.DB.Exec(m.Dialect.rebind(stmt), key, t, t.Add(-window))
+	if err != nil {
+		return LoginAttempts{}, err
+	}
+
+	return m.Get(key)
+}
+
+// Forgive() takes back one failure of the key (the callers count an attempt before knowing whether it failed)
+func (m *LoginAttemptModel) Forgive(key string) error {
+	stmt := `UPDATE login_attempts SET failures = failures - 1 WHERE attempt_key = ? AND failures > 0`
+
+	_, err := m.DB.Exec(m.Dialect.rebind(stmt), key)
+	return err
+}
+
+func (m *LoginAttemptModel) Reset(key string) error {
+	_, err := m.DB.Exec(m.Dialect.rebind(`DELETE FROM login_attempts WHERE attempt_key = ?`), key)
+	return err
+}
+
+// deletes up to limit keys without a failure inside the window and returns how many were deleted
+func (m *LoginAttemptModel) DeleteStale(window time.Duration, limit int) (int, error) {
+	stmt := `DELETE FROM login_attempts WHERE attempt_key IN (SELECT attempt_key FROM login_attempts WHERE last_failure < ? LIMIT ?)`
+	if m.Dialect == MySQL {
+		stmt = `DELETE FROM login_attempts WHERE last_failure < ? LIMIT ?`
+	}
+
+	result, err := m.DB.Exec(m.Dialect.rebind(stmt), now().Add(-window), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rows), nil
+}
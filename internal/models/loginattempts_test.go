@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestLoginAttemptModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, dialect := newTestDB(t)
+
+	m := LoginAttemptModel{DB: db, Dialect: dialect}
+
+	a, err := m.Get("ip:192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, a.Failures, 0)
+
+	for want := 1; want <= 3; want++ {
+		a, err = m.Fail("ip:192.0.2.1", time.Hour)
+		assert.NilError(t, err)
+		assert.Equal(t, a.Failures, want)
+	}
+
+	a, err = m.Get("ip:192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, a.Failures, 3)
+
+	t.Run("Forgive", func(t *testing.T) {
+		assert.NilError(t, m.Forgive("ip:192.0.2.1"))
+
+		a, err := m.Get("ip:192.0.2.1")
+		assert.NilError(t, err)
+		assert.Equal(t, a.Failures, 2)
+
+		_, err = m.Fail("ip:192.0.2.1", time.Hour)
+		assert.NilError(t, err)
+
+		// NOTE: a key without failures is left alone
+		assert.NilError(t, m.Forgive("ip:192.0.2.2"))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		_, err := db.Exec(dialect.rebind(`UPDATE login_attempts SET last_failure = ? WHERE attempt_key = ?`), now().Add(-2*time.Hour), "ip:192.0.2.1")
+		assert.NilError(t, err)
+
+		// NOTE: the old failures are forgotten
+		a, err := m.Fail("ip:192.0.2.1", time.Hour)
+		assert.NilError(t, err)
+		assert.Equal(t, a.Failures, 1)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		assert.NilError(t, m.Reset("ip:192.0.2.1"))
+
+		a, err := m.Get("ip:192.0.2.1")
+		assert.NilError(t, err)
+		assert.Equal(t, a.Failures, 0)
+	})
+
+	t.Run("DeleteStale", func(t *testing.T) {
+		_, err := m.Fail("email:stale", time.Hour)
+		assert.NilError(t, err)
+
+		_, err = m.Fail("email:recent", time.Hour)
+		assert.NilError(t, err)
+
+		_, err = db.Exec(dialect.rebind(`UPDATE login_attempts SET last_failure = ? WHERE attempt_key = ?`), now().Add(-2*time.Hour), "email:stale")
+		assert.NilError(t, err)
+
+		n, err := m.DeleteStale(time.Hour, 100)
+		assert.NilError(t, err)
+		assert.Equal(t, n, 1)
+
+		a, err := m.Get("email:recent")
+		assert.NilError(t, err)
+		assert.Equal(t, a.Failures, 1)
+	})
+}
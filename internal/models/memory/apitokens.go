@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"slices"
+	"sync"
+
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+type apiToken struct {
+	models.APIToken
+	hash [sha256.Size]byte
+}
+
+type APITokenModel struct {
+	mu     sync.RWMutex
+	tokens []*apiToken
+	lastID int
+}
+
+func (m *APITokenModel) Insert(userID int, name string) (string, error) {
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	m.tokens = append(m.tokens, &apiToken{
+		APIToken: models.APIToken{ID: m.lastID, UserID: userID, Name: name, Created: now()},
+		hash:     sha256.Sum256([]byte(plaintext)),
+	})
+
+	return plaintext, nil
+}
+
+func (m *APITokenModel) ByUser(userID int) ([]*models.APIToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tokens := []*models.APIToken{}
+
+	for _, t := range slices.Backward(m.tokens) {
+		if t.UserID == userID {
+			c := t.APIToken
+			tokens = append(tokens, &c)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (m *APITokenModel) Delete(id, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.tokens, func(t *apiToken) bool {
+		return t.ID == id && t.UserID == userID
+	})
+	if i == -1 {
+		return models.ErrNoRecord
+	}
+
+	m.tokens = slices.Delete(m.tokens, i, i+1)
+
+	return nil
+}
+
+func (m *APITokenModel) Authenticate(plaintext string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	for _, t := range m.tokens {
+		if t.hash == hash {
+			return t.UserID, nil
+		}
+	}
+
+	return 0, models.ErrInvalidCredentials
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+type LoginAttemptModel struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempts
+}
+
+func (m *LoginAttemptModel) Get(key string) (models.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.attempts[key], nil
+}
+
+func (m *LoginAttemptModel) Fail(key string, window time.Duration) (models.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.attempts == nil {
+		m.attempts = make(map[string]models.LoginAttempts)
+	}
+
+	t := now()
+
+	a := m.attempts[key]
+	if a.LastFailure.Before(t.Add(-window)) {
+		a.Failures = 0
+	}
+
+	a.Failures++
+	a.LastFailure = t
+	m.attempts[key] = a
+
+	return a, nil
+}
+
+func (m *LoginAttemptModel) Forgive(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a, ok := m.attempts[key]; ok && a.Failures > 0 {
+		a.Failures--
+		m.attempts[key] = a
+	}
+
+	return nil
+}
+
+func (m *LoginAttemptModel) Reset(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+
+	return nil
+}
+
+func (m *LoginAttemptModel) DeleteStale(window time.Duration, limit int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for key, a := range m.attempts {
+		if n == limit {
+			break
+		}
+
+		if a.LastFailure.Before(now().Add(-window)) {
+			delete(m.attempts, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestLoginAttemptModel(t *testing.T) {
+	m := &LoginAttemptModel{}
+
+	a, err := m.Get("ip:192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, a.Failures, 0)
+
+	for want := 1; want <= 3; want++ {
+		a, err = m.Fail("ip:192.0.2.1", time.Hour)
+		assert.NilError(t, err)
+		assert.Equal(t, a.Failures, want)
+	}
+
+	assert.NilError(t, m.Forgive("ip:192.0.2.1"))
+
+	a, err = m.Get("ip:192.0.2.1")
+	assert.NilError(t, err)
+	assert.Equal(t, a.Failures, 2)
+
+	assert.NilError(t, m.Forgive("ip:192.0.2.2"))
+
+	// NOTE: with a negative window every earlier failure is too old to count
+	a, err = m.Fail("ip:192.0.2.1", -time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, a.Failures, 1)
+
+	n, err := m.DeleteStale(time.Hour, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, n, 0)
+
+	n, err = m.DeleteStale(-time.Second, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, n, 1)
+
+	_, err = m.Fail("email:x", time.Hour)
+	assert.NilError(t, err)
+	assert.NilError(t, m.Reset("email:x"))
+
+	a, err = m.Get("email:x")
+	assert.NilError(t, err)
+	assert.Equal(t, a.Failures, 0)
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"sync"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type passwordReset struct {
+	userID  int
+	expires time.Time
+}
+
+// NOTE: needs the users to look the email up and to set the new password
+type PasswordResetModel struct {
+	Users *UserModel
+
+	mu     sync.Mutex
+	resets map[[sha256.Size]byte]passwordReset // NOTE: keyed by the token's hash like in the db
+}
+
+func (m *PasswordResetModel) Insert(email string, ttl time.Duration) (string, error) {
+	userID, ok := m.Users.idByEmail(email)
+	if !ok {
+		return "", models.ErrNoRecord
+	}
+
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.resets == nil {
+		m.resets = make(map[[sha256.Size]byte]passwordReset)
+	}
+
+	m.resets[sha256.Sum256([]byte(plaintext))] = passwordReset{userID: userID, expires: now().Add(ttl)}
+
+	return plaintext, nil
+}
+
+func (m *PasswordResetModel) UserID(plaintext string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.resets[sha256.Sum256([]byte(plaintext))]
+	if !ok || !r.expires.After(now()) {
+		return 0, models.ErrNoRecord
+	}
+
+	return r.userID, nil
+}
+
+func (m *PasswordResetModel) Reset(plaintext, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.resets[sha256.Sum256([]byte(plaintext))]
+	if !ok || !r.expires.After(now()) {
+		return models.ErrNoRecord
+	}
+
+	// NOTE: all of the user's tokens are used up, not just this one
+	for hash, other := range m.resets {
+		if other.userID == r.userID {
+			delete(m.resets, hash)
+		}
+	}
+
+	m.Users.setPassword(r.userID, hashedPassword)
+
+	return nil
+}
+
+func (m *PasswordResetModel) DeleteExpired(limit int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for hash, r := range m.resets {
+		if n == limit {
+			break
+		}
+
+		if r.expires.Before(now()) {
+			delete(m.resets, hash)
+			n++
+		}
+	}
+
+	return n, nil
+}
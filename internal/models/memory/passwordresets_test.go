@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+func TestPasswordResetModel(t *testing.T) {
+	users := &UserModel{}
+	m := &PasswordResetModel{Users: users}
+
+	_, err := users.Insert("Bob", "bob@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	_, err = m.Insert("nobody@example.com", time.Hour)
+	assert.Equal(t, err, models.ErrNoRecord)
+
+	token, err := m.Insert("BOB@example.com", time.Hour)
+	assert.NilError(t, err)
+
+	other, err := m.Insert("bob@example.com", time.Hour)
+	assert.NilError(t, err)
+
+	expired, err := m.Insert("bob@example.com", -time.Minute)
+	assert.NilError(t, err)
+
+	_, err = m.UserID(expired)
+	assert.Equal(t, err, models.ErrNoRecord)
+
+	userID, err := m.UserID(token)
+	assert.NilError(t, err)
+	assert.Equal(t, userID, 1)
+
+	err = m.Reset(token, "new-pa$$word")
+	assert.NilError(t, err)
+
+	_, err = users.Authenticate("bob@example.com", "new-pa$$word")
+	assert.NilError(t, err)
+
+	err = m.Reset(token, "pa$$word")
+	assert.Equal(t, err, models.ErrNoRecord)
+
+	err = m.Reset(other, "pa$$word")
+	assert.Equal(t, err, models.ErrNoRecord)
+}
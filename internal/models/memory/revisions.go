@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"slices"
+
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+// NOTE: the caller must hold the write lock
+func (m *SnippetModel) recordRevision(s *models.Snippet) {
+	m.lastRevisionID++
+	m.revisions[s.ID] = append(m.revisions[s.ID], models.Revision{
+		ID:        m.lastRevisionID,
+		SnippetID: s.ID,
+		Number:    len(m.revisions[s.ID]) + 1,
+		Title:     s.Title,
+		Content:   s.Content,
+		UserID:    s.UserID,
+		Created:   now(),
+	})
+}
+
+func (m *SnippetModel) Revisions(snippetID int) ([]*models.Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revisions := []*models.Revision{}
+
+	for _, r := range slices.Backward(m.revisions[snippetID]) {
+		if m.Users != nil {
+			r.AuthorName = m.Users.name(r.UserID)
+		}
+
+		revisions = append(revisions, &r)
+	}
+
+	return revisions, nil
+}
+
+func (m *SnippetModel) Restore(snippetID, revisionID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.revisions[snippetID], func(r models.Revision) bool {
+		return r.ID == revisionID
+	})
+	if i == -1 {
+		return models.ErrNoRecord
+	}
+
+	s := m.snippets[snippetID]
+	s.Title = m.revisions[snippetID][i].Title
+	s.Content = m.revisions[snippetID][i].Content
+
+	m.recordRevision(s)
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package memory
+
+// NOTE: with the in-memory storage the sessions live in scs' own memstore which deletes the expired ones itself
+type SessionModel struct{}
+
+func (m *SessionModel) DeleteExpired(limit int) (int, error) {
+	return 0, nil
+}
@@ -0,0 +1,343 @@
+// in-memory implementations of the model interfaces, for running without a database (-storage=memory) and for
+// tests that need a real create-then-view flow. they follow the same semantics as the sql models (expiry,
+// visibility, duplicate emails, ...) and are safe for concurrent use. the zero values are ready to use.
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SnippetModel struct {
+	Users *UserModel // NOTE: where the revision authors' names come from (optional)
+
+	mu             sync.RWMutex
+	snippets       map[int]*models.Snippet
+	revisions      map[int][]models.Revision // NOTE: by snippet ID, oldest first
+	lastID         int
+	lastRevisionID int
+}
+
+// NOTE: same as the sql models, truncated to seconds like a mysql DATETIME
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// returns the expiry time relative to now or the zero time for a snippet that never expires
+func expiresAt(expires time.Duration) time.Time {
+	if expires <= 0 {
+		return time.Time{}
+	}
+
+	return now().Add(expires)
+}
+
+func hashPassword(password string) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	return bcrypt.GenerateFromPassword([]byte(password), 12)
+}
+
+func newSlug() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NOTE: the snippets are handed out as copies so the callers can't change the stored ones
+func clone(s *models.Snippet) *models.Snippet {
+	c := *s
+	c.HashedPassword = slices.Clone(s.HashedPassword)
+	return &c
+}
+
+func live(s *models.Snippet, t time.Time) bool {
+	return s.NeverExpires() || s.Expires.After(t)
+}
+
+func (m *SnippetModel) Insert(title, content, language, visibility, password string, burnAfterReading bool, expires time.Duration, userID, forkedFrom int) (int, error) {
+	slug, err := newSlug()
+	if err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.snippets == nil {
+		m.snippets = make(map[int]*models.Snippet)
+		m.revisions = make(map[int][]models.Revision)
+	}
+
+	m.lastID++
+	m.snippets[m.lastID] = &models.Snippet{
+		ID:               m.lastID,
+		Slug:             slug,
+		Title:            title,
+		Content:          content,
+		Language:         language,
+		Visibility:       visibility,
+		HashedPassword:   hashedPassword,
+		BurnAfterReading: burnAfterReading,
+		Created:          now(),
+		Expires:          expiresAt(expires),
+		UserID:           userID,
+		ForkedFrom:       forkedFrom,
+	}
+
+	m.recordRevision(m.snippets[m.lastID])
+
+	return m.lastID, nil
+}
+
+func (m *SnippetModel) Get(id int) (*models.Snippet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.snippets[id]
+	if !ok || !live(s, now()) {
+		return nil, models.ErrNoRecord
+	}
+
+	return clone(s), nil
+}
+
+func (m *SnippetModel) GetBySlug(slug string) (*models.Snippet, error) {
+	snippets := m.filter(func(s *models.Snippet, t time.Time) bool {
+		return s.Slug == slug && live(s, t)
+	}, 0, 1, false)
+
+	if len(snippets) == 0 {
+		return nil, models.ErrNoRecord
+	}
+
+	return snippets[0], nil
+}
+
+func (m *SnippetModel) Latest() ([]*models.Snippet, error) {
+	return m.ListBefore(0, 10)
+}
+
+// NOTE: includes the expired ones, same as the sql model
+func (m *SnippetModel) ByUser(userID int) ([]*models.Snippet, error) {
+	return m.filter(func(s *models.Snippet, t time.Time) bool {
+		return s.UserID == userID
+	}, 0, -1, false), nil
+}
+
+// NOTE: an empty password keeps the current one and an expires of 0 means never
+func (m *SnippetModel) Update(id int, title, content, language, visibility, password string, burnAfterReading bool, expires time.Duration) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: generated up front so the lock isn't held for it, only used if the visibility changes
+	slug, err := newSlug()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.snippets[id]
+	if !ok {
+		return nil // NOTE: same as an UPDATE that matches no rows
+	}
+
+	// NOTE: same as the sql model, a new slug when it's made unlisted or private
+	if visibility != s.Visibility && visibility != models.VisibilityPublic {
+		s.Slug = slug
+	}
+
+	changed := s.Title != title || s.Content != content
+
+	s.Title = title
+	s.Content = content
+	s.Language = language
+	s.Visibility = visibility
+	s.BurnAfterReading = burnAfterReading
+	s.Expires = expiresAt(expires)
+
+	if hashedPassword != nil {
+		s.HashedPassword = hashedPassword
+	}
+
+	if changed {
+		m.recordRevision(s)
+	}
+
+	return nil
+}
+
+func (m *SnippetModel) Delete(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.snippets[id]; !ok {
+		return models.ErrNoRecord
+	}
+
+	m.delete(id)
+
+	return nil
+}
+
+// NOTE: the lock makes reading and deleting a single step so only one reader gets the snippet
+func (m *SnippetModel) Burn(id int) (*models.Snippet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.snippets[id]
+	if !ok || !s.BurnAfterReading || !live(s, now()) {
+		return nil, models.ErrNoRecord
+	}
+
+	m.delete(id)
+
+	return s, nil
+}
+
+// NOTE: like the sqlite model, any of the words as a (case-insensitive) substring of the title or content
+func (m *SnippetModel) Search(query string, limit, offset int) ([]*models.Snippet, error) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return []*models.Snippet{}, nil
+	}
+
+	return m.filter(func(s *models.Snippet, t time.Time) bool {
+		if !searchable(s, t) {
+			return false
+		}
+
+		text := strings.ToLower(s.Title + " " + s.Content)
+
+		return slices.ContainsFunc(words, func(word string) bool {
+			return strings.Contains(text, word)
+		})
+	}, offset, limit, false), nil
+}
+
+func searchable(s *models.Snippet, t time.Time) bool {
+	return s.Visibility == models.VisibilityPublic && !s.IsProtected() && !s.BurnAfterReading && live(s, t)
+}
+
+// NOTE: same as the sql model, the burn after reading ones are never listed (the first visitor would destroy them)
+func listed(s *models.Snippet, t time.Time) bool {
+	return s.Visibility == models.VisibilityPublic && !s.BurnAfterReading && live(s, t)
+}
+
+func (m *SnippetModel) ListBefore(id, limit int) ([]*models.Snippet, error) {
+	return m.filter(func(s *models.Snippet, t time.Time) bool {
+		return (id == 0 || s.ID < id) && listed(s, t)
+	}, 0, limit, false), nil
+}
+
+func (m *SnippetModel) ListAfter(id, limit int) ([]*models.Snippet, error) {
+	// NOTE: ascending so the limit keeps the ones closest to id, reversed below
+	snippets := m.filter(func(s *models.Snippet, t time.Time) bool {
+		return s.ID > id && listed(s, t)
+	}, 0, limit, true)
+
+	slices.Reverse(snippets)
+
+	return snippets, nil
+}
+
+func (m *SnippetModel) Forks(id int) ([]*models.Snippet, error) {
+	return m.filter(func(s *models.Snippet, t time.Time) bool {
+		return s.ForkedFrom == id && live(s, t)
+	}, 0, -1, false), nil
+}
+
+func (m *SnippetModel) DeleteExpired(grace time.Duration, limit int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := now().Add(-grace)
+	deleted := 0
+
+	for id, s := range m.snippets {
+		if deleted == limit {
+			break
+		}
+
+		if !s.NeverExpires() && s.Expires.Before(cutoff) {
+			m.delete(id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// NOTE: deletes the revisions too and unlinks the forks, like the foreign keys do. the caller must hold the write lock
+func (m *SnippetModel) delete(id int) {
+	delete(m.snippets, id)
+	delete(m.revisions, id)
+
+	for _, s := range m.snippets {
+		if s.ForkedFrom == id {
+			s.ForkedFrom = 0
+		}
+	}
+}
+
+// returns copies of the snippets matching keep ordered by ID (newest first unless ascending), skipping the first
+// offset of them and returning at most limit (-1 for all)
+func (m *SnippetModel) filter(keep func(s *models.Snippet, t time.Time) bool, offset, limit int, ascending bool) []*models.Snippet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t := now()
+	matching := []*models.Snippet{}
+
+	for _, s := range m.snippets {
+		if keep(s, t) {
+			matching = append(matching, s)
+		}
+	}
+
+	slices.SortFunc(matching, func(a, b *models.Snippet) int {
+		if ascending {
+			return a.ID - b.ID
+		}
+
+		return b.ID - a.ID
+	})
+
+	if offset >= len(matching) {
+		return []*models.Snippet{}
+	}
+	matching = matching[offset:]
+
+	if limit >= 0 && limit < len(matching) {
+		matching = matching[:limit]
+	}
+
+	snippets := make([]*models.Snippet, len(matching))
+	for i, s := range matching {
+		snippets[i] = clone(s)
+	}
+
+	return snippets
+}
@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+func TestSnippetModelExpiry(t *testing.T) {
+	m := &SnippetModel{}
+
+	liveID, err := m.Insert("live", "content", "go", models.VisibilityPublic, "", false, time.Hour, 1, 0)
+	assert.NilError(t, err)
+
+	neverID, err := m.Insert("never", "content", "go", models.VisibilityPublic, "", false, 0, 1, 0)
+	assert.NilError(t, err)
+
+	expiredID, err := m.Insert("expired", "content", "go", models.VisibilityPublic, "", false, time.Hour, 1, 0)
+	assert.NilError(t, err)
+
+	// NOTE: backdating it instead of waiting for it to expire
+	m.snippets[expiredID].Expires = now().Add(-2 * time.Hour)
+
+	_, err = m.Get(liveID)
+	assert.NilError(t, err)
+
+	s, err := m.Get(neverID)
+	assert.NilError(t, err)
+	assert.Equal(t, s.NeverExpires(), true)
+
+	_, err = m.Get(expiredID)
+	assert.Equal(t, err, models.ErrNoRecord)
+
+	_, err = m.GetBySlug(m.snippets[expiredID].Slug)
+	assert.Equal(t, err, models.ErrNoRecord)
+
+	latest, err := m.Latest()
+	assert.NilError(t, err)
+	assert.Equal(t, len(latest), 2)
+	assert.Equal(t, latest[0].ID, neverID)
+
+	// NOTE: the owner still sees the expired ones
+	byUser, err := m.ByUser(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(byUser), 3)
+
+	n, err := m.DeleteExpired(3*time.Hour, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, n, 0)
+
+	n, err = m.DeleteExpired(time.Hour, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, n, 1)
+}
+
+func TestSnippetModelCopies(t *testing.T) {
+	m := &SnippetModel{}
+
+	id, err := m.Insert("title", "content", "go", models.VisibilityPublic, "", false, time.Hour, 1, 0)
+	assert.NilError(t, err)
+
+	s, err := m.Get(id)
+	assert.NilError(t, err)
+	s.Title = "changed"
+
+	s, err = m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, s.Title, "title")
+}
+
+func TestSnippetModelSlugRotation(t *testing.T) {
+	m := &SnippetModel{}
+
+	id, err := m.Insert("title", "content", "go", models.VisibilityPublic, "", false, time.Hour, 1, 0)
+	assert.NilError(t, err)
+
+	public, err := m.Get(id)
+	assert.NilError(t, err)
+
+	assert.NilError(t, m.Update(id, "title", "content", "go", models.VisibilityUnlisted, "", false, time.Hour))
+
+	unlisted, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, unlisted.Slug != public.Slug, true)
+
+	_, err = m.GetBySlug(public.Slug)
+	assert.Equal(t, err, models.ErrNoRecord)
+
+	// NOTE: an edit that keeps it unlisted keeps the link working
+	assert.NilError(t, m.Update(id, "changed", "content", "go", models.VisibilityUnlisted, "", false, time.Hour))
+
+	s, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, s.Slug, unlisted.Slug)
+}
+
+func TestSnippetModelListing(t *testing.T) {
+	m := &SnippetModel{}
+
+	for i := 1; i <= 5; i++ {
+		_, err := m.Insert("title", "content", "go", models.VisibilityPublic, "", false, time.Hour, 1, 0)
+		assert.NilError(t, err)
+	}
+	_, err := m.Insert("unlisted", "content", "go", models.VisibilityUnlisted, "", false, time.Hour, 1, 0)
+	assert.NilError(t, err)
+	_, err = m.Insert("burn", "content", "go", models.VisibilityPublic, "", true, time.Hour, 1, 0)
+	assert.NilError(t, err)
+
+	ids := func(snippets []*models.Snippet) string {
+		ids := []int{}
+		for _, s := range snippets {
+			ids = append(ids, s.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	before, err := m.ListBefore(4, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, ids(before), "[3 2]")
+
+	after, err := m.ListAfter(2, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, ids(after), "[4 3]")
+
+	// NOTE: neither the unlisted nor the burn after reading one is listed
+	latest, err := m.Latest()
+	assert.NilError(t, err)
+	assert.Equal(t, ids(latest), "[5 4 3 2 1]")
+
+	after, err = m.ListAfter(4, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, ids(after), "[5]")
+
+	found, err := m.Search("TITLE missing", 10, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, ids(found), "[4 3 2 1]")
+}
+
+func TestSnippetModelBurnConcurrently(t *testing.T) {
+	m := &SnippetModel{}
+
+	id, err := m.Insert("burn", "content", "go", models.VisibilityPublic, "", true, time.Hour, 1, 0)
+	assert.NilError(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	burned := 0
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := m.Burn(id)
+			if err == nil {
+				mu.Lock()
+				burned++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// NOTE: only one of the readers gets to see it
+	assert.Equal(t, burned, 1)
+}
+
+func TestSnippetModelRevisions(t *testing.T) {
+	users := &UserModel{}
+	_, err := users.Insert("Bob", "bob@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	m := &SnippetModel{Users: users}
+
+	id, err := m.Insert("first", "one", "go", models.VisibilityPublic, "", false, time.Hour, 1, 0)
+	assert.NilError(t, err)
+
+	assert.NilError(t, m.Update(id, "second", "two", "go", models.VisibilityPublic, "", false, time.Hour))
+	assert.NilError(t, m.Update(id, "second", "two", "python", models.VisibilityPublic, "", false, time.Hour))
+
+	revisions, err := m.Revisions(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(revisions), 2)
+	assert.Equal(t, revisions[0].Number, 2)
+	assert.Equal(t, revisions[0].AuthorName, "Bob")
+
+	assert.NilError(t, m.Restore(id, revisions[1].ID))
+
+	s, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, s.Content, "one")
+	assert.Equal(t, s.Language, "python")
+
+	assert.Equal(t, m.Restore(id, 42), models.ErrNoRecord)
+
+	assert.NilError(t, m.Delete(id))
+
+	revisions, err = m.Revisions(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(revisions), 0)
+}
+
+func TestSnippetModelForks(t *testing.T) {
+	m := &SnippetModel{}
+
+	sourceID, err := m.Insert("source", "content", "go", models.VisibilityPublic, "", false, 0, 1, 0)
+	assert.NilError(t, err)
+
+	forkID, err := m.Insert("fork", "content", "go", models.VisibilityPrivate, "", false, 0, 2, sourceID)
+	assert.NilError(t, err)
+
+	forks, err := m.Forks(sourceID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(forks), 1)
+	assert.Equal(t, forks[0].ID, forkID)
+
+	// NOTE: deleting the original unlinks the fork
+	assert.NilError(t, m.Delete(sourceID))
+
+	fork, err := m.Get(forkID)
+	assert.NilError(t, err)
+	assert.Equal(t, fork.ForkedFrom, 0)
+}
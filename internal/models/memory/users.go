@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/harshk200/snippetbox/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UserModel struct {
+	mu     sync.RWMutex
+	users  map[int]*models.User
+	emails map[string]int // NOTE: lower-cased email -> id, emails are unique case-insensitively like in the db
+	lastID int
+}
+
+func (m *UserModel) Insert(name, email, password string) (int, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.users == nil {
+		m.users = make(map[int]*models.User)
+		m.emails = make(map[string]int)
+	}
+
+	key := strings.ToLower(email)
+	if _, exists := m.emails[key]; exists {
+		return 0, models.ErrDuplicateEmail
+	}
+
+	m.lastID++
+	m.users[m.lastID] = &models.User{ID: m.lastID, Name: name, Email: email, HashedPassword: hashedPassword, Created: now()}
+	m.emails[key] = m.lastID
+
+	return m.lastID, nil
+}
+
+func (m *UserModel) Authenticate(email, password string) (int, error) {
+	m.mu.RLock()
+	id, ok := m.emails[strings.ToLower(email)]
+	var hashedPassword []byte
+	if ok {
+		hashedPassword = m.users[id].HashedPassword
+	}
+	m.mu.RUnlock()
+
+	if !ok {
+		return 0, models.ErrInvalidCredentials
+	}
+
+	// NOTE: outside of the lock as bcrypt is slow on purpose
+	err := checkPassword(hashedPassword, password)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (m *UserModel) Exists(id int) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.users[id]
+	return ok, nil
+}
+
+func (m *UserModel) Get(id int) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	c := *u
+	c.HashedPassword = slices.Clone(u.HashedPassword)
+
+	return &c, nil
+}
+
+func (m *UserModel) PasswordUpdate(id int, currentPassword, newPassword string) error {
+	m.mu.RLock()
+	u, ok := m.users[id]
+	var hashedPassword []byte
+	if ok {
+		hashedPassword = u.HashedPassword
+	}
+	m.mu.RUnlock()
+
+	if !ok {
+		return models.ErrNoRecord
+	}
+
+	err := checkPassword(hashedPassword, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	newHashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
+	if err != nil {
+		return err
+	}
+
+	m.setPassword(id, newHashedPassword)
+
+	return nil
+}
+
+func (m *UserModel) Verify(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return models.ErrNoRecord
+	}
+
+	u.Verified = true
+
+	return nil
+}
+
+// returns the id of the user with the email (case-insensitively), false if there is none
+func (m *UserModel) idByEmail(email string) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.emails[strings.ToLower(email)]
+	return id, ok
+}
+
+func (m *UserModel) setPassword(id int, hashedPassword []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[id]; ok {
+		u.HashedPassword = hashedPassword
+	}
+}
+
+// NOTE: a wrong password is ErrInvalidCredentials like in the sql model
+func checkPassword(hashedPassword []byte, password string) error {
+	err := bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return models.ErrInvalidCredentials
+	}
+
+	return err
+}
+
+// returns the name of the user or "" if there is no such user
+func (m *UserModel) name(id int) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if u, ok := m.users[id]; ok {
+		return u.Name
+	}
+
+	return ""
+}
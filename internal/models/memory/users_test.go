@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+func TestUserModel(t *testing.T) {
+	m := &UserModel{}
+
+	id, err := m.Insert("Bob", "bob@example.com", "pa$$word")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 1)
+
+	_, err = m.Insert("Bob", "Bob@Example.com", "pa$$word")
+	assert.Equal(t, err, models.ErrDuplicateEmail)
+
+	id, err = m.Authenticate("BOB@example.com", "pa$$word")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 1)
+
+	_, err = m.Authenticate("bob@example.com", "wrong")
+	assert.Equal(t, err, models.ErrInvalidCredentials)
+
+	_, err = m.Authenticate("alice@example.com", "pa$$word")
+	assert.Equal(t, err, models.ErrInvalidCredentials)
+
+	exists, err := m.Exists(id)
+	assert.NilError(t, err)
+	assert.Equal(t, exists, true)
+
+	exists, err = m.Exists(2)
+	assert.NilError(t, err)
+	assert.Equal(t, exists, false)
+
+	u, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, u.Name, "Bob")
+	assert.Equal(t, u.Email, "bob@example.com")
+
+	assert.Equal(t, u.Verified, false)
+
+	_, err = m.Get(2)
+	assert.Equal(t, err, models.ErrNoRecord)
+
+	assert.NilError(t, m.Verify(id))
+	assert.Equal(t, m.Verify(2), models.ErrNoRecord)
+
+	u, err = m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, u.Verified, true)
+
+	err = m.PasswordUpdate(id, "wrong", "new-pa$$word")
+	assert.Equal(t, err, models.ErrInvalidCredentials)
+
+	err = m.PasswordUpdate(id, "pa$$word", "new-pa$$word")
+	assert.NilError(t, err)
+
+	_, err = m.Authenticate("bob@example.com", "pa$$word")
+	assert.Equal(t, err, models.ErrInvalidCredentials)
+
+	_, err = m.Authenticate("bob@example.com", "new-pa$$word")
+	assert.NilError(t, err)
+}
+
+func TestUserModelInsertConcurrently(t *testing.T) {
+	m := &UserModel{}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+
+	// NOTE: half of them race for the same email
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = m.Insert("user", fmt.Sprintf("user%d@example.com", i%2), "pa$$word")
+		}()
+	}
+
+	wg.Wait()
+
+	duplicates := 0
+	for _, err := range errs {
+		if err == models.ErrDuplicateEmail {
+			duplicates++
+		} else {
+			assert.NilError(t, err)
+		}
+	}
+
+	assert.Equal(t, duplicates, 2)
+}
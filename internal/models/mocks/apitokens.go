@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+var mockAPIToken = &models.APIToken{
+	ID:      1,
+	UserID:  1,
+	Name:    "ci...",
+	Created: time.Now(),
+}
+
+type APITokenModel struct{}
+
+func (m *APITokenModel) Insert(userID int, name string) (string, error) {
+	return "MOCKTOKENPLAINTEXT", nil
+}
+
+func (m *APITokenModel) ByUser(userID int) ([]*models.APIToken, error) {
+	switch userID {
+	case 1:
+		return []*models.APIToken{mockAPIToken}, nil
+	default:
+		return []*models.APIToken{}, nil
+	}
+}
+
+func (m *APITokenModel) Delete(id, userID int) error {
+	if id == 1 && userID == 1 {
+		return nil
+	}
+
+	return models.ErrNoRecord
+}
+
+func (m *APITokenModel) Authenticate(plaintext string) (int, error) {
+	switch plaintext {
+	case "valid-token":
+		return 1, nil
+	default:
+		return 0, models.ErrInvalidCredentials
+	}
+}
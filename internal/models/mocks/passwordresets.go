@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/models"
+)
+
+type PasswordResetModel struct{}
+
+func (m *PasswordResetModel) Insert(email string, ttl time.Duration) (string, error) {
+	switch email {
+	case "test@example.com":
+		return "MOCKRESETTOKEN", nil
+	default:
+		return "", models.ErrNoRecord
+	}
+}
+
+func (m *PasswordResetModel) UserID(plaintext string) (int, error) {
+	switch plaintext {
+	case "MOCKRESETTOKEN":
+		return 1, nil
+	default:
+		return 0, models.ErrNoRecord
+	}
+}
+
+func (m *PasswordResetModel) Reset(plaintext, newPassword string) error {
+	switch plaintext {
+	case "MOCKRESETTOKEN":
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *PasswordResetModel) DeleteExpired(limit int) (int, error) {
+	return 0, nil
+}
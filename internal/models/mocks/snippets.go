@@ -1,22 +1,115 @@
 package mocks
 
 import (
+	"strings"
 	"time"
 
 	"github.com/harshk200/snippetbox/internal/models"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var mockSnippet = &models.Snippet{
-	ID:      69,
-	Title:   "test...",
-	Content: "test-content...",
-	Created: time.Now(),
-	Expires: time.Now(),
+	ID:         69,
+	Slug:       "mockSlugPublic69",
+	Title:      "test...",
+	Content:    "test-content...",
+	Language:   "go",
+	Visibility: models.VisibilityPublic,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(time.Hour * 24),
+	UserID:     1,
+}
+
+// NOTE: a snippet owned by some other user (used for testing the ownership checks)
+var mockOtherSnippet = &models.Snippet{
+	ID:         42,
+	Slug:       "mockSlugPublic42",
+	Title:      "other...",
+	Content:    "other-content...",
+	Language:   "plaintext",
+	Visibility: models.VisibilityPublic,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(time.Hour * 24),
+	UserID:     2,
+}
+
+// NOTE: non-public snippets owned by the other user (used for testing the visibility checks)
+var mockUnlistedSnippet = &models.Snippet{
+	ID:         43,
+	Slug:       "mockSlugUnlisted",
+	Title:      "unlisted...",
+	Content:    "unlisted-content...",
+	Language:   "plaintext",
+	Visibility: models.VisibilityUnlisted,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(time.Hour * 24),
+	UserID:     2,
+}
+
+var mockPrivateSnippet = &models.Snippet{
+	ID:         44,
+	Slug:       "mockSlugPrivate",
+	Title:      "private...",
+	Content:    "private-content...",
+	Language:   "plaintext",
+	Visibility: models.VisibilityPrivate,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(time.Hour * 24),
+	UserID:     2,
+}
+
+// NOTE: public but password protected with the password "secret" (MinCost to keep the tests fast)
+var mockProtectedSnippet = func() *models.Snippet {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+
+	return &models.Snippet{
+		ID:             45,
+		Slug:           "mockSlugProtected",
+		Title:          "protected...",
+		Content:        "protected-content...",
+		Language:       "plaintext",
+		Visibility:     models.VisibilityPublic,
+		HashedPassword: hashedPassword,
+		Created:        time.Now(),
+		Expires:        time.Now().Add(time.Hour * 24),
+		UserID:         2,
+	}
+}()
+
+// NOTE: burn after reading snippet owned by the other user
+var mockBurnSnippet = &models.Snippet{
+	ID:               46,
+	Slug:             "mockSlugBurn",
+	Title:            "burn...",
+	Content:          "burn-content...",
+	Language:         "plaintext",
+	Visibility:       models.VisibilityPublic,
+	BurnAfterReading: true,
+	Created:          time.Now(),
+	Expires:          time.Now().Add(time.Hour * 24),
+	UserID:           2,
+}
+
+// NOTE: the other user's fork of mockSnippet
+var mockForkSnippet = &models.Snippet{
+	ID:         47,
+	Slug:       "mockSlugFork",
+	Title:      "fork...",
+	Content:    "fork-content...",
+	Language:   "go",
+	Visibility: models.VisibilityPublic,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(time.Hour * 24),
+	UserID:     2,
+	ForkedFrom: 69,
 }
 
 type SnippetModel struct{}
 
-func (m *SnippetModel) Insert(title string, content string, expires int) (int, error) {
+func (m *SnippetModel) Insert(title, content, language, visibility, password string, burnAfterReading bool, expires time.Duration, userID, forkedFrom int) (int, error) {
 	return 420, nil
 }
 
@@ -24,11 +117,136 @@ func (m *SnippetModel) Get(id int) (*models.Snippet, error) {
 	switch id {
 	case 69:
 		return mockSnippet, nil
+	case 42:
+		return mockOtherSnippet, nil
+	case 43:
+		return mockUnlistedSnippet, nil
+	case 44:
+		return mockPrivateSnippet, nil
+	case 45:
+		return mockProtectedSnippet, nil
+	case 46:
+		return mockBurnSnippet, nil
+	case 47:
+		return mockForkSnippet, nil
 	default:
 		return nil, models.ErrNoRecord
 	}
 }
 
+func (m *SnippetModel) GetBySlug(slug string) (*models.Snippet, error) {
+	for _, s := range []*models.Snippet{mockSnippet, mockOtherSnippet, mockUnlistedSnippet, mockPrivateSnippet, mockProtectedSnippet, mockBurnSnippet, mockForkSnippet} {
+		if s.Slug == slug {
+			return s, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
 func (m *SnippetModel) Latest() ([]*models.Snippet, error) {
 	return []*models.Snippet{mockSnippet}, nil
 }
+
+func (m *SnippetModel) ByUser(userID int) ([]*models.Snippet, error) {
+	switch userID {
+	case 1:
+		return []*models.Snippet{mockSnippet}, nil
+	default:
+		return []*models.Snippet{}, nil
+	}
+}
+
+func (m *SnippetModel) Update(id int, title, content, language, visibility, password string, burnAfterReading bool, expires time.Duration) error {
+	switch id {
+	case 69, 42:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) Delete(id int) error {
+	switch id {
+	case 69, 42:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) Search(query string, limit, offset int) ([]*models.Snippet, error) {
+	if strings.Contains(strings.ToLower(query), "test") && offset == 0 {
+		return []*models.Snippet{mockSnippet}, nil
+	}
+
+	return []*models.Snippet{}, nil
+}
+
+func (m *SnippetModel) ListBefore(id, limit int) ([]*models.Snippet, error) {
+	switch id {
+	case 0:
+		return []*models.Snippet{mockSnippet}, nil
+	default:
+		return []*models.Snippet{}, nil
+	}
+}
+
+func (m *SnippetModel) ListAfter(id, limit int) ([]*models.Snippet, error) {
+	switch id {
+	case 1:
+		return []*models.Snippet{mockSnippet}, nil
+	default:
+		return []*models.Snippet{}, nil
+	}
+}
+
+func (m *SnippetModel) Burn(id int) (*models.Snippet, error) {
+	switch id {
+	case 46:
+		return mockBurnSnippet, nil
+	default:
+		return nil, models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) DeleteExpired(grace time.Duration, limit int) (int, error) {
+	return 0, nil
+}
+
+// NOTE: mockSnippet was edited once, the other snippets only have the version they were created with
+func (m *SnippetModel) Revisions(snippetID int) ([]*models.Revision, error) {
+	switch snippetID {
+	case 69:
+		return []*models.Revision{
+			{ID: 2, SnippetID: 69, Number: 2, Title: mockSnippet.Title, Content: mockSnippet.Content, UserID: 1, AuthorName: "Mock User", Created: mockSnippet.Created},
+			{ID: 1, SnippetID: 69, Number: 1, Title: "first...", Content: "first-content...", UserID: 1, AuthorName: "Mock User", Created: mockSnippet.Created},
+		}, nil
+	default:
+		s, err := m.Get(snippetID)
+		if err != nil {
+			return []*models.Revision{}, nil
+		}
+
+		return []*models.Revision{
+			{ID: 100 + s.ID, SnippetID: s.ID, Number: 1, Title: s.Title, Content: s.Content, UserID: s.UserID, AuthorName: "Other User", Created: s.Created},
+		}, nil
+	}
+}
+
+func (m *SnippetModel) Restore(snippetID, revisionID int) error {
+	if snippetID == 69 && (revisionID == 1 || revisionID == 2) {
+		return nil
+	}
+
+	return models.ErrNoRecord
+}
+
+func (m *SnippetModel) Forks(id int) ([]*models.Snippet, error) {
+	switch id {
+	case 69:
+		return []*models.Snippet{mockForkSnippet}, nil
+	default:
+		return []*models.Snippet{}, nil
+	}
+}
@@ -1,17 +1,35 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/harshk200/snippetbox/internal/models"
 )
 
+var mockUser = &models.User{
+	ID:       1,
+	Name:     "Mock User",
+	Email:    "test@example.com",
+	Created:  time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC),
+	Verified: true,
+}
+
+// NOTE: signed up but never opened the verification email
+var mockUnverifiedUser = &models.User{
+	ID:      2,
+	Name:    "Unverified User",
+	Email:   "unverified@example.com",
+	Created: time.Date(2022, 1, 2, 10, 0, 0, 0, time.UTC),
+}
+
 type UserModel struct{}
 
-func (m *UserModel) Insert(name, email, password string) error {
+func (m *UserModel) Insert(name, email, password string) (int, error) {
 	switch email {
 	case "dupe@example.com":
-		return models.ErrDuplicateEmail
+		return 0, models.ErrDuplicateEmail
 	default:
-		return nil
+		return 3, nil
 	}
 }
 
@@ -20,14 +38,50 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 		return 1, nil
 	}
 
+	if email == "unverified@example.com" && password == "password" {
+		return 2, nil
+	}
+
 	return 0, models.ErrInvalidCredentials
 }
 
 func (m *UserModel) Exists(id int) (bool, error) {
 	switch id {
-	case 1:
+	case 1, 2:
 		return true, nil
 	default:
 		return false, nil
 	}
 }
+
+func (m *UserModel) Get(id int) (*models.User, error) {
+	switch id {
+	case 1:
+		return mockUser, nil
+	case 2:
+		return mockUnverifiedUser, nil
+	default:
+		return nil, models.ErrNoRecord
+	}
+}
+
+func (m *UserModel) PasswordUpdate(id int, currentPassword, newPassword string) error {
+	if id != 1 {
+		return models.ErrNoRecord
+	}
+
+	if currentPassword != "password" {
+		return models.ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+func (m *UserModel) Verify(id int) error {
+	switch id {
+	case 1, 2:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PasswordResetModelInterface interface {
+	Insert(email string, ttl time.Duration) (string, error)
+	UserID(plaintext string) (int, error)
+	Reset(plaintext, newPassword string) error
+	DeleteExpired(limit int) (int, error)
+}
+
+// NOTE: the reset tokens are single-use and only valid for a while, like the api tokens only their sha256 hash is
+// stored so a leaked db can't be used to take over the accounts
+type PasswordResetModel struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+// creates a reset token for the user with the email and returns it's plaintext. returns ErrNoRecord if there is no
+// such user (the caller must not tell that apart from a success)
+func (m *PasswordResetModel) Insert(email string, ttl time.Duration) (string, error) {
+	var userID int
+
+	query := `SELECT id FROM users WHERE ` + m.Dialect.emailEquals()
+
+	err := m.DB.QueryRow(m.Dialect.rebind(query), email).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNoRecord
+		}
+
+		return "", err
+	}
+
+	plaintext, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	stmt := `INSERT INTO password_resets (user_id, hash, expires)
+    VALUES(?, ?, ?)`
+
+	_, err = m.DB.Exec(m.Dialect.rebind(stmt), userID, hashToken(plaintext), now().Add(ttl))
+	if err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+// UserID() returns the ID of the user the token belongs to, ErrNoRecord if it's unknown, used or expired
+func (m *PasswordResetModel) UserID(plaintext string) (int, error) {
+	var userID int
+
+	query := `SELECT user_id FROM password_resets WHERE hash = ? AND expires > ?`
+
+	err := m.DB.QueryRow(m.Dialect.rebind(query), hashToken(plaintext), now()).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoRecord
+		}
+
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// Reset() sets the new password of the token's user and uses the token up (along with any other token of the user).
+// returns ErrNoRecord if the token is unknown, used or expired
+func (m *PasswordResetModel) Reset(plaintext, newPassword string) error {
+	// NOTE: hashing before the transaction so the row isn't locked while bcrypt runs
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // NOTE: no-op once the transaction is committed
+
+	var userID int
+
+	query := `SELECT user_id FROM password_resets WHERE hash = ? AND expires > ?` + m.Dialect.forUpdate()
+
+	err = tx.QueryRow(m.Dialect.rebind(query), hashToken(plaintext), now()).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+
+		return err
+	}
+
+	_, err = tx.Exec(m.Dialect.rebind(`DELETE FROM password_resets WHERE user_id = ?`), userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(m.Dialect.rebind(`UPDATE users SET hashed_password = ? WHERE id = ?`), hashedPassword, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deletes up to limit expired tokens and returns how many were deleted
+func (m *PasswordResetModel) DeleteExpired(limit int) (int, error) {
+	stmt := `DELETE FROM password_resets WHERE id IN (SELECT id FROM password_resets WHERE expires < ? LIMIT ?)`
+	if m.Dialect == MySQL {
+		stmt = `DELETE FROM password_resets WHERE expires < ? LIMIT ?`
+	}
+
+	result, err := m.DB.Exec(m.Dialect.rebind(stmt), now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rows), nil
+}
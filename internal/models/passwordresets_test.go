@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestPasswordResetModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, dialect := newTestDB(t)
+
+	users := UserModel{DB: db, Dialect: dialect}
+	m := PasswordResetModel{DB: db, Dialect: dialect}
+
+	_, err := users.Insert("Bob", "bob@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	t.Run("Unknown email", func(t *testing.T) {
+		_, err := m.Insert("nobody@example.com", time.Hour)
+		assert.Equal(t, err, ErrNoRecord)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		token, err := m.Insert("BOB@example.com", time.Hour)
+		assert.NilError(t, err)
+
+		other, err := m.Insert("bob@example.com", time.Hour)
+		assert.NilError(t, err)
+
+		userID, err := m.UserID(token)
+		assert.NilError(t, err)
+		assert.Equal(t, userID, 2)
+
+		err = m.Reset(token, "new-pa$$word")
+		assert.NilError(t, err)
+
+		_, err = users.Authenticate("bob@example.com", "new-pa$$word")
+		assert.NilError(t, err)
+
+		// NOTE: single-use, and using one token uses up the others too
+		err = m.Reset(token, "pa$$word")
+		assert.Equal(t, err, ErrNoRecord)
+
+		_, err = m.UserID(other)
+		assert.Equal(t, err, ErrNoRecord)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		token, err := m.Insert("bob@example.com", -time.Minute)
+		assert.NilError(t, err)
+
+		_, err = m.UserID(token)
+		assert.Equal(t, err, ErrNoRecord)
+
+		err = m.Reset(token, "pa$$word")
+		assert.Equal(t, err, ErrNoRecord)
+
+		n, err := m.DeleteExpired(100)
+		assert.NilError(t, err)
+		assert.Equal(t, n, 1)
+	})
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// a version of a snippet's title and content. every snippet has at least one (the one it was created with)
+type Revision struct {
+	ID         int
+	SnippetID  int
+	Number     int // NOTE: 1 for the snippet's first version, counted per snippet (not stored)
+	Title      string
+	Content    string
+	UserID     int
+	AuthorName string
+	Created    time.Time
+}
+
+// records the snippet's current title and content as a new revision inside the transaction that changed them.
+// NOTE: the author is the snippet's owner as only the owner can edit it
+func (m *SnippetModel) recordRevision(tx *sql.Tx, snippetID int) error {
+	stmt := `INSERT INTO revisions (snippet_id, title, content, user_id, created)
+    SELECT id, title, content, user_id, ? FROM snippets WHERE id = ?`
+
+	_, err := tx.Exec(m.Dialect.rebind(stmt), now(), snippetID)
+	return err
+}
+
+// returns all the revisions of the snippet with their authors' names (newest first)
+func (m *SnippetModel) Revisions(snippetID int) ([]*Revision, error) {
+	query := `SELECT r.id, r.snippet_id, r.title, r.content, r.user_id, u.name, r.created
+    FROM revisions r JOIN users u ON u.id = r.user_id
+    WHERE r.snippet_id = ? ORDER BY r.id DESC`
+
+	rows, err := m.DB.Query(m.Dialect.rebind(query), snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*Revision{}
+
+	for rows.Next() {
+		r := &Revision{}
+
+		err := rows.Scan(&r.ID, &r.SnippetID, &r.Title, &r.Content, &r.UserID, &r.AuthorName, &r.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	numberRevisions(revisions)
+
+	return revisions, nil
+}
+
+// NOTE: the revisions are newest first so the last one is the first version
+func numberRevisions(revisions []*Revision) {
+	for i, r := range revisions {
+		r.Number = len(revisions) - i
+	}
+}
+
+// sets the snippet's title and content back to the ones of the revision, which records them as a new revision (so
+// restoring can be undone too). ErrNoRecord means the revision doesn't belong to the snippet
+func (m *SnippetModel) Restore(snippetID, revisionID int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // NOTE: no-op once the transaction is committed
+
+	var title, content string
+
+	query := `SELECT title, content FROM revisions WHERE id = ? AND snippet_id = ?`
+
+	err = tx.QueryRow(m.Dialect.rebind(query), revisionID, snippetID).Scan(&title, &content)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+
+		return err
+	}
+
+	stmt := `UPDATE snippets SET title = ?, content = ? WHERE id = ?`
+
+	_, err = tx.Exec(m.Dialect.rebind(stmt), title, content, snippetID)
+	if err != nil {
+		return err
+	}
+
+	err = m.recordRevision(tx, snippetID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,41 @@
+package models
+
+import "database/sql"
+
+// NOTE: the sessions table itself belongs to scs (the session store reads and writes it), this only purges the expired
+// sessions so the sweeper can do that in batches together with the expired snippets
+type SessionModelInterface interface {
+	DeleteExpired(limit int) (int, error)
+}
+
+type SessionModel struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+// deletes up to limit expired sessions and returns how many were deleted
+func (m *SessionModel) DeleteExpired(limit int) (int, error) {
+	// NOTE: each scs store keeps the expiry in it's own way (sqlite3store as a julian day number)
+	var stmt string
+
+	switch m.Dialect {
+	case Postgres:
+		stmt = `DELETE FROM sessions WHERE token IN (SELECT token FROM sessions WHERE expiry < current_timestamp LIMIT ?)`
+	case SQLite:
+		stmt = `DELETE FROM sessions WHERE token IN (SELECT token FROM sessions WHERE expiry < julianday('now') LIMIT ?)`
+	default:
+		stmt = `DELETE FROM sessions WHERE expiry < UTC_TIMESTAMP(6) LIMIT ?`
+	}
+
+	result, err := m.DB.Exec(m.Dialect.rebind(stmt), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rows), nil
+}
@@ -1,56 +1,404 @@
 package models
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type SnippetModelInterface interface {
-	Insert(title, content string, expires int) (int, error)
+	Insert(title, content, language, visibility, password string, burnAfterReading bool, expires time.Duration, userID, forkedFrom int) (int, error)
 	Get(id int) (*Snippet, error)
+	GetBySlug(slug string) (*Snippet, error)
 	Latest() ([]*Snippet, error)
+	ByUser(userID int) ([]*Snippet, error)
+	Update(id int, title, content, language, visibility, password string, burnAfterReading bool, expires time.Duration) error
+	Delete(id int) error
+	Burn(id int) (*Snippet, error)
+	Search(query string, limit, offset int) ([]*Snippet, error)
+	ListBefore(id, limit int) ([]*Snippet, error)
+	ListAfter(id, limit int) ([]*Snippet, error)
+	DeleteExpired(grace time.Duration, limit int) (int, error)
+	Revisions(snippetID int) ([]*Revision, error)
+	Restore(snippetID, revisionID int) error
+	Forks(id int) ([]*Snippet, error)
 }
 
+// who can see a snippet. unlisted snippets are only reachable through their slug and private ones only by their owner
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
 // represents the data a single snippet holds
 type Snippet struct {
-	ID      int
-	Title   string
-	Content string
-	Created time.Time
-	Expires time.Time
+	ID               int       `json:"id"`
+	Slug             string    `json:"slug"` // NOTE: random and non-guessable unlike the ID, replaced whenever it is made unlisted or private
+	Title            string    `json:"title"`
+	Content          string    `json:"content"`
+	Language         string    `json:"language"`
+	Visibility       string    `json:"visibility"`
+	HashedPassword   []byte    `json:"-"`                  // NOTE: nil when the snippet isn't password protected
+	BurnAfterReading bool      `json:"burn_after_reading"` // NOTE: deleted the first time someone other than the owner views it
+	Created          time.Time `json:"created"`
+	Expires          time.Time `json:"expires"` // NOTE: the zero time means the snippet never expires
+	UserID           int       `json:"user_id"`
+	ForkedFrom       int       `json:"forked_from,omitempty"` // NOTE: the ID of the snippet this is a fork of, 0 if it isn't one
+}
+
+// NOTE: the columns every snippet query selects, in the same order as scanDest()
+const snippetColumns = `id, slug, title, content, language, visibility, hashed_password, burn_after_reading, created, expires, user_id, forked_from`
+
+func (s *Snippet) scanDest() []any {
+	return []any{&s.ID, &s.Slug, &s.Title, &s.Content, &s.Language, &s.Visibility, &s.HashedPassword, &s.BurnAfterReading, &s.Created, nullTime{&s.Expires}, &s.UserID, nullInt{&s.ForkedFrom}}
+}
+
+// NOTE: scans a nullable DATETIME into a time.Time, NULL becomes the zero time
+type nullTime struct {
+	t *time.Time
+}
+
+func (n nullTime) Scan(value any) error {
+	var nt sql.NullTime
+	err := nt.Scan(value)
+	if err != nil {
+		return err
+	}
+
+	*n.t = nt.Time // NOTE: nt.Time is the zero time when nt.Valid is false
+	return nil
+}
+
+// NOTE: scans a nullable INTEGER into an int, NULL becomes 0
+type nullInt struct {
+	n *int
+}
+
+func (n nullInt) Scan(value any) error {
+	var ni sql.NullInt64
+	err := ni.Scan(value)
+	if err != nil {
+		return err
+	}
+
+	*n.n = int(ni.Int64)
+	return nil
+}
+
+// NOTE: same as the default encoding except a snippet that never expires gets "expires": null
+func (s Snippet) MarshalJSON() ([]byte, error) {
+	type snippet Snippet // NOTE: without the MarshalJSON method, otherwise this would recurse
+
+	var expires *time.Time
+	if !s.Expires.IsZero() {
+		expires = &s.Expires
+	}
+
+	return json.Marshal(struct {
+		snippet
+		Expires *time.Time `json:"expires"`
+	}{snippet(s), expires})
+}
+
+func (s *Snippet) NeverExpires() bool {
+	return s.Expires.IsZero()
+}
+
+func (s *Snippet) IsProtected() bool {
+	return len(s.HashedPassword) > 0
+}
+
+// PasswordMatches() checks the password against the snippet's bcrypt hash (false if the snippet isn't protected)
+func (s *Snippet) PasswordMatches(password string) (bool, error) {
+	if !s.IsProtected() {
+		return false, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword(s.HashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// returns the bcrypt hash of the password the same way UserModel.Insert() does (nil for an empty password)
+func hashSnippetPassword(password string) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	return bcrypt.GenerateFromPassword([]byte(password), 12)
+}
+
+// returns what the snippet's links use as their :id, the slug for unlisted snippets (the only way others can open them)
+func (s *Snippet) Ref() string {
+	if s.Visibility == VisibilityUnlisted {
+		return s.Slug
+	}
+
+	return strconv.Itoa(s.ID)
+}
+
+// returns true if the snippet's expiry time has already passed
+func (s *Snippet) IsExpired() bool {
+	return !s.NeverExpires() && time.Now().After(s.Expires)
 }
 
 // a type with DB connection and methods on it to access and manipulate the snippets in the db
 type SnippetModel struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+// a snippet gets a new slug when it's made unlisted or private. while it was public anyone could have seen the slug
+// (e.g. in the api), so keeping it would leave the unlisted link protecting nothing
+func rotateSlug(oldVisibility, visibility string) bool {
+	return visibility != oldVisibility && visibility != VisibilityPublic
+}
+
+// returns a random url-safe 22 character slug
+func newSlug() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
 // NOTE: notice how we don't pass id and created_at parameters as they will be generated in the Insert func itself
-func (m *SnippetModel) Insert(title string, content string, expires int) (int, error) {
-	stmt := `INSERT INTO snippets (title, content, created, expires)
-    VALUES(?, ?, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? DAY))`
+// returns the expiry time relative to now or nil (NULL) for a snippet that never expires
+func expiresAt(expires time.Duration) any {
+	if expires <= 0 {
+		return nil
+	}
+
+	return now().Add(expires)
+}
+
+// NOTE: 0 means no ID, stored as NULL
+func nullIfZero(id int) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// NOTE: escapes the LIKE wildcards so they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// NOTE: the condition every query that returns snippets has, it's ? is now()
+const notExpired = `(expires IS NULL OR expires > ?)`
+
+// NOTE: an empty password means the snippet isn't password protected, an expires of 0 that it never expires and a
+// forkedFrom of 0 that it isn't a fork
+func (m *SnippetModel) Insert(title, content, language, visibility, password string, burnAfterReading bool, expires time.Duration, userID, forkedFrom int) (int, error) {
+	slug, err := newSlug()
+	if err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := hashSnippetPassword(password)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // NOTE: no-op once the transaction is committed
+
+	stmt := `INSERT INTO snippets (slug, title, content, language, visibility, hashed_password, burn_after_reading, created, expires, user_id, forked_from)
+    VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	id, err := m.Dialect.insert(tx, stmt, slug, title, content, language, visibility, hashedPassword, burnAfterReading, now(), expiresAt(expires), userID, nullIfZero(forkedFrom))
+	if err != nil {
+		return 0, err
+	}
 
-	result, err := m.DB.Exec(stmt, title, content, expires)
+	// NOTE: the first version is a revision too so the history always starts from the original
+	err = m.recordRevision(tx, id)
 	if err != nil {
 		return 0, err
 	}
 
-	id, err := result.LastInsertId()
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// deletes up to limit snippets that expired more than grace ago and returns how many were deleted.
+// NOTE: the grace period keeps recently expired snippets around so their owners still see them on the dashboard
+func (m *SnippetModel) DeleteExpired(grace time.Duration, limit int) (int, error) {
+	// NOTE: only mysql has DELETE ... LIMIT (and it doesn't allow a LIMIT in an IN subquery)
+	stmt := `DELETE FROM snippets WHERE id IN (SELECT id FROM snippets WHERE expires < ? LIMIT ?)`
+	if m.Dialect == MySQL {
+		stmt = `DELETE FROM snippets WHERE expires < ? LIMIT ?`
+	}
+
+	result, err := m.DB.Exec(m.Dialect.rebind(stmt), now().Add(-grace), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rows), nil
+}
+
+// NOTE: the expiry is set relative to now (0 for never) and an empty password keeps the current one. a new revision
+// is recorded when the title or content changed
+func (m *SnippetModel) Update(id int, title, content, language, visibility, password string, burnAfterReading bool, expires time.Duration) error {
+	hashedPassword, err := hashSnippetPassword(password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // NOTE: no-op once the transaction is committed
+
+	var oldTitle, oldContent, oldVisibility string
+
+	query := `SELECT title, content, visibility FROM snippets WHERE id = ?` + m.Dialect.forUpdate()
+
+	err = tx.QueryRow(m.Dialect.rebind(query), id).Scan(&oldTitle, &oldContent, &oldVisibility)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // NOTE: same as an UPDATE that matches no rows
+		}
+
+		return err
+	}
+
+	// NOTE: nil keeps the current slug
+	var slug *string
+	if rotateSlug(oldVisibility, visibility) {
+		newSlug, err := newSlug()
+		if err != nil {
+			return err
+		}
+
+		slug = &newSlug
+	}
+
+	stmt := `UPDATE snippets SET slug = COALESCE(?, slug), title = ?, content = ?, language = ?, visibility = ?,
+    hashed_password = COALESCE(?, hashed_password), burn_after_reading = ?,
+    expires = ? WHERE id = ?`
+
+	_, err = tx.Exec(m.Dialect.rebind(stmt), slug, title, content, language, visibility, hashedPassword, burnAfterReading, expiresAt(expires), id)
 	if err != nil {
-		return 0, nil
+		return err
+	}
+
+	if title != oldTitle || content != oldContent {
+		err = m.recordRevision(tx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *SnippetModel) Delete(id int) error {
+	stmt := `DELETE FROM snippets WHERE id = ?`
+
+	result, err := m.DB.Exec(m.Dialect.rebind(stmt), id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
 	}
 
-	return int(id), nil
+	return nil
+}
+
+// Burn() reads and deletes a burn after reading snippet in a single transaction. the row is locked while it's read
+// so when two readers race only one of them gets the snippet, the other one gets ErrNoRecord
+func (m *SnippetModel) Burn(id int) (*Snippet, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // NOTE: no-op once the transaction is committed
+
+	query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND burn_after_reading = TRUE AND id = ?` + m.Dialect.forUpdate()
+
+	s := &Snippet{}
+
+	err = tx.QueryRow(m.Dialect.rebind(query), now(), id).Scan(s.scanDest()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+
+		return nil, err
+	}
+
+	result, err := tx.Exec(m.Dialect.rebind(`DELETE FROM snippets WHERE id = ?`), id)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: shouldn't happen with the lock but this way a snippet can't be burned (and so read) twice no matter what
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rows == 0 {
+		return nil, ErrNoRecord
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
 }
 
 func (m *SnippetModel) Get(id int) (*Snippet, error) {
-	query := `SELECT id, title, content, created, expires FROM snippets
-    WHERE expires > UTC_TIMESTAMP() AND id = ?;`
+	query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND id = ?;`
 
 	s := &Snippet{}
 
-	err := m.DB.QueryRow(query, id).Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires)
+	err := m.DB.QueryRow(m.Dialect.rebind(query), now(), id).Scan(s.scanDest()...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -62,12 +410,33 @@ func (m *SnippetModel) Get(id int) (*Snippet, error) {
 	return s, nil
 }
 
-// returns the most recently created snippets (Multiple)
+// NOTE: same as Get() but looks the snippet up by it's random slug
+func (m *SnippetModel) GetBySlug(slug string) (*Snippet, error) {
+	query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND slug = ?;`
+
+	s := &Snippet{}
+
+	err := m.DB.QueryRow(m.Dialect.rebind(query), now(), slug).Scan(s.scanDest()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// returns the most recently created public snippets (Multiple).
+// NOTE: leaves out the burn after reading ones, like all the listings. the first visitor (or crawler) following the
+// link would destroy them
 func (m *SnippetModel) Latest() ([]*Snippet, error) {
-	query := `SELECT id, title, content, created, expires FROM snippets
-    WHERE expires > UTC_TIMESTAMP() ORDER BY id DESC LIMIT 10`
+	query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND visibility = 'public' AND burn_after_reading = FALSE ORDER BY id DESC LIMIT 10`
 
-	rows, err := m.DB.Query(query)
+	rows, err := m.DB.Query(m.Dialect.rebind(query), now())
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +448,128 @@ func (m *SnippetModel) Latest() ([]*Snippet, error) {
 	for rows.Next() {
 		s := &Snippet{}
 
-		err := rows.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires)
+		err := rows.Scan(s.scanDest()...)
+		if err != nil {
+			return nil, err
+		}
+
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
+
+// returns all the snippets created by the user with the provided ID (including the expired ones)
+func (m *SnippetModel) ByUser(userID int) ([]*Snippet, error) {
+	query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE user_id = ? ORDER BY id DESC`
+
+	return m.list(query, userID)
+}
+
+// returns the unexpired public snippets matching the query (ranked by relevance) using the FULLTEXT index on title and content.
+// password protected and burn after reading snippets are left out as matching on their content would leak it
+func (m *SnippetModel) Search(query string, limit, offset int) ([]*Snippet, error) {
+	searchable := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND visibility = 'public' AND hashed_password IS NULL AND burn_after_reading = FALSE`
+
+	switch m.Dialect {
+	case Postgres:
+		stmt := searchable + `
+    AND to_tsvector('simple', title || ' ' || content) @@ plainto_tsquery('simple', ?)
+    ORDER BY ts_rank(to_tsvector('simple', title || ' ' || content), plainto_tsquery('simple', ?)) DESC, id DESC
+    LIMIT ? OFFSET ?`
+
+		return m.list(stmt, now(), query, query, limit, offset)
+	case SQLite:
+		// NOTE: no full-text index, any of the words as a substring of the title or content (newest first)
+		words := strings.Fields(query)
+		if len(words) == 0 {
+			return []*Snippet{}, nil
+		}
+
+		conditions := make([]string, len(words))
+		args := []any{now()}
+
+		for i, word := range words {
+			conditions[i] = `title LIKE ? ESCAPE '\' OR content LIKE ? ESCAPE '\'`
+			pattern := "%" + likeEscaper.Replace(word) + "%"
+			args = append(args, pattern, pattern)
+		}
+
+		stmt := searchable + ` AND (` + strings.Join(conditions, " OR ") + `) ORDER BY id DESC LIMIT ? OFFSET ?`
+
+		return m.list(stmt, append(args, limit, offset)...)
+	default:
+		stmt := searchable + `
+    AND MATCH(title, content) AGAINST(? IN NATURAL LANGUAGE MODE)
+    ORDER BY MATCH(title, content) AGAINST(? IN NATURAL LANGUAGE MODE) DESC, id DESC
+    LIMIT ? OFFSET ?`
+
+		return m.list(stmt, now(), query, query, limit, offset)
+	}
+}
+
+// returns upto limit unexpired public snippets (but not the burn after reading ones) older than the snippet with the
+// provided ID (newest first). an ID of 0 starts from the newest snippet
+func (m *SnippetModel) ListBefore(id, limit int) ([]*Snippet, error) {
+	if id == 0 {
+		query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND visibility = 'public' AND burn_after_reading = FALSE ORDER BY id DESC LIMIT ?`
+
+		return m.list(query, now(), limit)
+	}
+
+	query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND visibility = 'public' AND burn_after_reading = FALSE AND id < ? ORDER BY id DESC LIMIT ?`
+
+	return m.list(query, now(), id, limit)
+}
+
+// returns upto limit unexpired public snippets (but not the burn after reading ones) newer than the snippet with the
+// provided ID (still newest first)
+func (m *SnippetModel) ListAfter(id, limit int) ([]*Snippet, error) {
+	// NOTE: ascending so the LIMIT keeps the ones closest to id, reversed below
+	query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND visibility = 'public' AND burn_after_reading = FALSE AND id > ? ORDER BY id ASC LIMIT ?`
+
+	snippets, err := m.list(query, now(), id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(snippets)
+
+	return snippets, nil
+}
+
+// returns the unexpired snippets forked from the snippet with the provided ID, whatever their visibility (newest first)
+func (m *SnippetModel) Forks(id int) ([]*Snippet, error) {
+	query := `SELECT ` + snippetColumns + ` FROM snippets
+    WHERE ` + notExpired + ` AND forked_from = ? ORDER BY id DESC`
+
+	return m.list(query, now(), id)
+}
+
+// runs the query and scans every returned row into a snippet
+// NOTE: rebinds the query itself
+func (m *SnippetModel) list(query string, args ...any) ([]*Snippet, error) {
+	rows, err := m.DB.Query(m.Dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []*Snippet{}
+
+	for rows.Next() {
+		s := &Snippet{}
+
+		err := rows.Scan(s.scanDest()...)
 		if err != nil {
 			return nil, err
 		}
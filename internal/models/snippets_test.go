@@ -0,0 +1,209 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harshk200/snippetbox/internal/assert"
+)
+
+func TestSnippetModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, dialect := newTestDB(t)
+
+	m := SnippetModel{DB: db, Dialect: dialect}
+
+	id, err := m.Insert("An old silent pond", "A frog jumps into the pond", "plaintext", VisibilityPublic, "", false, time.Hour, 1, 0)
+	assert.NilError(t, err)
+
+	t.Run("Get", func(t *testing.T) {
+		s, err := m.Get(id)
+		assert.NilError(t, err)
+		assert.Equal(t, s.Title, "An old silent pond")
+		assert.Equal(t, s.NeverExpires(), false)
+		assert.Equal(t, s.IsProtected(), false)
+
+		bySlug, err := m.GetBySlug(s.Slug)
+		assert.NilError(t, err)
+		assert.Equal(t, bySlug.ID, id)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		snippets, err := m.Search("frog", 10, 0)
+		assert.NilError(t, err)
+		assert.Equal(t, len(snippets), 1)
+
+		snippets, err = m.Search("toad", 10, 0)
+		assert.NilError(t, err)
+		assert.Equal(t, len(snippets), 0)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		err := m.Update(id, "Autumn moonlight", "A worm digs silently", "plaintext", VisibilityPublic, "secret", false, 0)
+		assert.NilError(t, err)
+
+		s, err := m.Get(id)
+		assert.NilError(t, err)
+		assert.Equal(t, s.Title, "Autumn moonlight")
+		assert.Equal(t, s.NeverExpires(), true)
+
+		ok, err := s.PasswordMatches("secret")
+		assert.NilError(t, err)
+		assert.Equal(t, ok, true)
+	})
+
+	t.Run("Slug rotation", func(t *testing.T) {
+		publicID, err := m.Insert("Public", "content", "plaintext", VisibilityPublic, "", false, time.Hour, 1, 0)
+		assert.NilError(t, err)
+
+		public, err := m.Get(publicID)
+		assert.NilError(t, err)
+
+		// NOTE: the slug was out there while it was public, so making it unlisted needs a new one
+		err = m.Update(publicID, "Public", "content", "plaintext", VisibilityUnlisted, "", false, time.Hour)
+		assert.NilError(t, err)
+
+		unlisted, err := m.Get(publicID)
+		assert.NilError(t, err)
+		assert.Equal(t, unlisted.Slug != public.Slug, true)
+
+		_, err = m.GetBySlug(public.Slug)
+		assert.Equal(t, err, ErrNoRecord)
+
+		err = m.Update(publicID, "Unlisted", "content", "plaintext", VisibilityUnlisted, "", false, time.Hour)
+		assert.NilError(t, err)
+
+		s, err := m.Get(publicID)
+		assert.NilError(t, err)
+		assert.Equal(t, s.Slug, unlisted.Slug)
+
+		assert.NilError(t, m.Delete(publicID))
+	})
+
+	t.Run("Revisions", func(t *testing.T) {
+		revisions, err := m.Revisions(id)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 2)
+		assert.Equal(t, revisions[0].Title, "Autumn moonlight")
+		assert.Equal(t, revisions[0].Number, 2)
+		assert.Equal(t, revisions[0].AuthorName, "Alice Jones")
+		assert.Equal(t, revisions[1].Content, "A frog jumps into the pond")
+
+		// NOTE: only the title and content are versioned
+		err = m.Update(id, "Autumn moonlight", "A worm digs silently", "go", VisibilityPublic, "", false, 0)
+		assert.NilError(t, err)
+
+		revisions, err = m.Revisions(id)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 2)
+
+		err = m.Restore(id, revisions[1].ID)
+		assert.NilError(t, err)
+
+		s, err := m.Get(id)
+		assert.NilError(t, err)
+		assert.Equal(t, s.Title, "An old silent pond")
+		assert.Equal(t, s.Language, "go")
+
+		revisions, err = m.Revisions(id)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 3)
+		assert.Equal(t, revisions[0].Content, "A frog jumps into the pond")
+
+		otherID, err := m.Insert("Other", "snippet", "plaintext", VisibilityPublic, "", false, time.Hour, 1, 0)
+		assert.NilError(t, err)
+
+		err = m.Restore(otherID, revisions[1].ID)
+		assert.Equal(t, err, ErrNoRecord)
+
+		// NOTE: the revisions go with the snippet
+		assert.NilError(t, m.Delete(otherID))
+
+		revisions, err = m.Revisions(otherID)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 0)
+	})
+
+	t.Run("Forks", func(t *testing.T) {
+		forkID, err := m.Insert("Fork", "A frog jumps", "plaintext", VisibilityPrivate, "", false, 0, 1, id)
+		assert.NilError(t, err)
+
+		fork, err := m.Get(forkID)
+		assert.NilError(t, err)
+		assert.Equal(t, fork.ForkedFrom, id)
+
+		forks, err := m.Forks(id)
+		assert.NilError(t, err)
+		assert.Equal(t, len(forks), 1)
+		assert.Equal(t, forks[0].ID, forkID)
+
+		// NOTE: the fork outlives the original
+		sourceID, err := m.Insert("Source", "content", "plaintext", VisibilityPublic, "", false, 0, 1, 0)
+		assert.NilError(t, err)
+
+		orphanID, err := m.Insert("Fork", "content", "plaintext", VisibilityPublic, "", false, 0, 1, sourceID)
+		assert.NilError(t, err)
+
+		assert.NilError(t, m.Delete(sourceID))
+
+		fork, err = m.Get(orphanID)
+		assert.NilError(t, err)
+		assert.Equal(t, fork.ForkedFrom, 0)
+
+		assert.NilError(t, m.Delete(orphanID))
+		assert.NilError(t, m.Delete(forkID))
+	})
+
+	t.Run("Burn", func(t *testing.T) {
+		burnID, err := m.Insert("Burn", "after reading", "plaintext", VisibilityPublic, "", true, time.Hour, 1, 0)
+		assert.NilError(t, err)
+
+		// NOTE: public but never listed, the first visitor following the link would destroy it
+		for _, list := range []func() ([]*Snippet, error){
+			m.Latest,
+			func() ([]*Snippet, error) { return m.ListBefore(0, 100) },
+			func() ([]*Snippet, error) { return m.ListAfter(burnID-1, 100) },
+		} {
+			snippets, err := list()
+			assert.NilError(t, err)
+
+			for _, s := range snippets {
+				assert.Equal(t, s.ID != burnID, true)
+			}
+		}
+
+		s, err := m.Burn(burnID)
+		assert.NilError(t, err)
+		assert.Equal(t, s.Content, "after reading")
+
+		_, err = m.Burn(burnID)
+		assert.Equal(t, err, ErrNoRecord)
+	})
+
+	t.Run("DeleteExpired", func(t *testing.T) {
+		expiredID, err := m.Insert("Expired", "soon", "plaintext", VisibilityPublic, "", false, time.Hour, 1, 0)
+		assert.NilError(t, err)
+
+		_, err = db.Exec(dialect.rebind(`UPDATE snippets SET expires = ? WHERE id = ?`), now().Add(-2*time.Hour), expiredID)
+		assert.NilError(t, err)
+
+		_, err = m.Get(expiredID)
+		assert.Equal(t, err, ErrNoRecord)
+
+		// NOTE: still inside the grace period
+		n, err := m.DeleteExpired(3*time.Hour, 100)
+		assert.NilError(t, err)
+		assert.Equal(t, n, 0)
+
+		n, err = m.DeleteExpired(time.Hour, 100)
+		assert.NilError(t, err)
+		assert.Equal(t, n, 1)
+
+		snippets, err := m.ByUser(1)
+		assert.NilError(t, err)
+		assert.Equal(t, len(snippets), 1)
+	})
+}
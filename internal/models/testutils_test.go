@@ -4,31 +4,65 @@ import (
 	"database/sql"
 	"os"
 	"testing"
+
+	"github.com/harshk200/snippetbox/internal/migrations"
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
-func newTestDB(t *testing.T) *sql.DB {
-	db, err := sql.Open("mysql", "test_web:password@/test_snippetbox?parseTime=true&interpolateParams=true&multiStatements=true")
+// NOTE: the integration tests run against mysql by default, SNIPPETBOX_TEST_DSN points them at another database
+// e.g. SNIPPETBOX_TEST_DSN=sqlite://:memory: runs them without a db server
+func newTestDB(t *testing.T) (*sql.DB, Dialect) {
+	dsn := os.Getenv("SNIPPETBOX_TEST_DSN")
+	if dsn == "" {
+		dsn = "test_web:password@/test_snippetbox?parseTime=true&interpolateParams=true&multiStatements=true"
+	}
+
+	dialect, source, err := ParseDSN(dsn)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	script, err := os.ReadFile("./testdata/setup.sql")
+	driver := map[Dialect]string{MySQL: "mysql", Postgres: "pgx", SQLite: "sqlite"}[dialect]
+
+	db, err := sql.Open(driver, source)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	_, err = db.Exec(string(script))
+	if dialect == SQLite {
+		db.SetMaxOpenConns(1) // NOTE: every connection to :memory: would get it's own db
+	}
+
+	// NOTE: the same schema as production, built by the migrations
+	_, err = migrations.Up(db, dialect.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt := `INSERT INTO users (name, email, hashed_password, created) VALUES (
+    'Alice Jones',
+    'alice@example.com',
+    '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG',
+    '2022-01-01 10:00:00'
+)`
+
+	_, err = db.Exec(stmt)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	t.Cleanup(func() {
-		script, err := os.ReadFile("./testdata/teardown.sql")
+		all, err := migrations.All(dialect.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = migrations.Down(db, dialect.String(), len(all))
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		_, err = db.Exec(string(script))
+		_, err = db.Exec(`DROP TABLE schema_migrations`)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -36,5 +70,5 @@ func newTestDB(t *testing.T) *sql.DB {
 		db.Close() // i could have used defer db.Close() as well in the main func
 	})
 
-	return db
+	return db, dialect
 }
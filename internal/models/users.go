@@ -3,56 +3,56 @@ package models
 import (
 	"database/sql"
 	"errors"
-	"strings"
 	"time"
 
-	"github.com/go-sql-driver/mysql"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserModelInterface interface {
-	Insert(name, email, password string) error
+	Insert(name, email, password string) (int, error)
 	Authenticate(email, password string) (int, error)
 	Exists(id int) (bool, error)
+	Get(id int) (*User, error)
+	PasswordUpdate(id int, currentPassword, newPassword string) error
+	Verify(id int) error
 }
 
 type User struct {
-	ID              int
-	name            string
-	email           string
-	hashed_password []byte
-	create          time.Time
+	ID             int
+	Name           string
+	Email          string
+	HashedPassword []byte
+	Created        time.Time
+	Verified       bool // NOTE: whether the user opened the link in the verification email
 }
 
 type UserModel struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Dialect Dialect
 }
 
 // inserts a new user in the database with the provided values. if failed returns an error
-func (m *UserModel) Insert(name, email, password string) error {
+// creates an unverified user and returns it's ID
+func (m *UserModel) Insert(name, email, password string) (int, error) {
 	hashed_password, err := bcrypt.GenerateFromPassword([]byte(password), 12)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	stmt := `INSERT INTO users (name, email, hashed_password, created)
-    VALUES(?, ?, ?, UTC_TIMESTAMP())`
+    VALUES(?, ?, ?, ?)`
 
-	_, err = m.DB.Exec(stmt, name, email, hashed_password)
+	id, err := m.Dialect.insert(m.DB, stmt, name, email, hashed_password, now())
 	if err != nil {
-		var mySQLError *mysql.MySQLError
-
-		// NOTE: errors.As() writes the matching error to the target if it exists
-		if errors.As(err, &mySQLError) {
-			if mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "users_uc_email") {
-				return ErrDuplicateEmail
-			}
+		// NOTE: each database reports the violated unique constraint differently
+		if m.Dialect.isDuplicateEmail(err) {
+			return 0, ErrDuplicateEmail
 		}
 
-		return err
+		return 0, err
 	}
 
-	return nil
+	return id, nil
 }
 
 // Authenticate() checks if the user exists with the povided email and password and returns there userID
@@ -60,10 +60,10 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 	var id int
 	var hashed_password []byte
 
-	stmt := `SELECT id, hashed_password FROM users WHERE email = ?;`
+	stmt := `SELECT id, hashed_password FROM users WHERE ` + m.Dialect.emailEquals()
 
 	// NOTE: we are using queryrow beacuse this stmt returns a single row
-	err := m.DB.QueryRow(stmt, email).Scan(&id, &hashed_password)
+	err := m.DB.QueryRow(m.Dialect.rebind(stmt), email).Scan(&id, &hashed_password)
 	if err != nil {
 		return 0, ErrInvalidCredentials
 	}
@@ -85,6 +85,88 @@ func (m *UserModel) Exists(id int) (bool, error) {
 	var exists bool
 	stmt := `SELECT EXISTS(SELECT true FROM users WHERE id = ?)`
 
-	err := m.DB.QueryRow(stmt, id).Scan(&exists)
+	err := m.DB.QueryRow(m.Dialect.rebind(stmt), id).Scan(&exists)
 	return exists, err
 }
+
+// returns the user with the provided ID (ErrNoRecord if there is none)
+func (m *UserModel) Get(id int) (*User, error) {
+	u := &User{}
+
+	stmt := `SELECT id, name, email, hashed_password, created, verified FROM users WHERE id = ?`
+
+	err := m.DB.QueryRow(m.Dialect.rebind(stmt), id).Scan(&u.ID, &u.Name, &u.Email, &u.HashedPassword, &u.Created, &u.Verified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// PasswordUpdate() replaces the user's password after checking the current one (ErrInvalidCredentials if it's wrong)
+func (m *UserModel) PasswordUpdate(id int, currentPassword, newPassword string) error {
+	var currentHashedPassword []byte
+
+	stmt := `SELECT hashed_password FROM users WHERE id = ?`
+
+	err := m.DB.QueryRow(m.Dialect.rebind(stmt), id).Scan(&currentHashedPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword(currentHashedPassword, []byte(currentPassword))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		}
+
+		return err
+	}
+
+	newHashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
+	if err != nil {
+		return err
+	}
+
+	stmt = `UPDATE users SET hashed_password = ? WHERE id = ?`
+
+	_, err = m.DB.Exec(m.Dialect.rebind(stmt), newHashedPassword, id)
+	return err
+}
+
+// marks the user's email as verified (ErrNoRecord if there is no such user)
+func (m *UserModel) Verify(id int) error {
+	stmt := `UPDATE users SET verified = TRUE WHERE id = ?`
+
+	result, err := m.DB.Exec(m.Dialect.rebind(stmt), id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// NOTE: mysql doesn't count the rows that were already verified (nothing changed) as affected
+	if rows == 0 {
+		exists, err := m.Exists(id)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return ErrNoRecord
+		}
+	}
+
+	return nil
+}
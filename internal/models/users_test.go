@@ -35,9 +35,9 @@ func TestUserModelsExists(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			db := newTestDB(t)
+			db, dialect := newTestDB(t)
 
-			m := UserModel{DB: db}
+			m := UserModel{DB: db, Dialect: dialect}
 
 			exists, err := m.Exists(tt.userID)
 
@@ -46,3 +46,103 @@ func TestUserModelsExists(t *testing.T) {
 		})
 	}
 }
+
+func TestUserModelInsert(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, dialect := newTestDB(t)
+
+	m := UserModel{DB: db, Dialect: dialect}
+
+	id, err := m.Insert("Bob", "bob@example.com", "pa$$word")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 2)
+
+	// NOTE: the duplicate check is case-insensitive on every backend
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{name: "Seeded email", email: "alice@example.com"},
+		{name: "Same email", email: "bob@example.com"},
+		{name: "Different case", email: "Bob@Example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Insert("Bob", tt.email, "pa$$word")
+
+			assert.Equal(t, err, ErrDuplicateEmail)
+		})
+	}
+
+	t.Run("Authenticate", func(t *testing.T) {
+		id, err := m.Authenticate("BOB@example.com", "pa$$word")
+		assert.NilError(t, err)
+		assert.Equal(t, id, 2)
+
+		_, err = m.Authenticate("bob@example.com", "wrong")
+		assert.Equal(t, err, ErrInvalidCredentials)
+	})
+}
+
+func TestUserModelPasswordUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, dialect := newTestDB(t)
+
+	m := UserModel{DB: db, Dialect: dialect}
+
+	_, err := m.Insert("Bob", "bob@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	t.Run("Get", func(t *testing.T) {
+		u, err := m.Get(2)
+		assert.NilError(t, err)
+		assert.Equal(t, u.Name, "Bob")
+		assert.Equal(t, u.Email, "bob@example.com")
+		assert.Equal(t, u.Verified, false)
+
+		_, err = m.Get(69)
+		assert.Equal(t, err, ErrNoRecord)
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		assert.NilError(t, m.Verify(2))
+
+		// NOTE: verifying twice is fine
+		assert.NilError(t, m.Verify(2))
+
+		u, err := m.Get(2)
+		assert.NilError(t, err)
+		assert.Equal(t, u.Verified, true)
+
+		assert.Equal(t, m.Verify(69), ErrNoRecord)
+	})
+
+	t.Run("Wrong current password", func(t *testing.T) {
+		err := m.PasswordUpdate(2, "wrong", "new-pa$$word")
+		assert.Equal(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("Non-existent ID", func(t *testing.T) {
+		err := m.PasswordUpdate(69, "pa$$word", "new-pa$$word")
+		assert.Equal(t, err, ErrNoRecord)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		err := m.PasswordUpdate(2, "pa$$word", "new-pa$$word")
+		assert.NilError(t, err)
+
+		_, err = m.Authenticate("bob@example.com", "pa$$word")
+		assert.Equal(t, err, ErrInvalidCredentials)
+
+		id, err := m.Authenticate("bob@example.com", "new-pa$$word")
+		assert.NilError(t, err)
+		assert.Equal(t, id, 2)
+	})
+}